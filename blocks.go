@@ -0,0 +1,302 @@
+package main
+
+import (
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+)
+
+func (cfg *apiConfig) blockUserHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	blockedID, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user tries to block themselves
+	if blockedID == userID {
+		err = respondWithError(rw, http.StatusBadRequest, "Cannot block yourself")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure blocked user exists
+	_, err = cfg.db.GetUserByID(rq.Context(), blockedID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "User not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the block and the removed follows are saved together
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error blocking user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Insert block into database (blocking twice is a no-op)
+	dbParams := database.BlockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	}
+
+	err = qtx.BlockUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error blocking user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Blocking removes follows in both directions
+	unfollowParams := []database.UnfollowUserParams{
+		{FollowerID: userID, FolloweeID: blockedID},
+		{FollowerID: blockedID, FolloweeID: userID},
+	}
+
+	for _, params := range unfollowParams {
+		_, err = qtx.UnfollowUser(rq.Context(), params)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error blocking user")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error blocking user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) unblockUserHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	blockedID, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Delete block from database
+	dbParams := database.UnblockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	}
+
+	_, err = cfg.db.UnblockUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error unblocking user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) muteUserHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	mutedID, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user tries to mute themselves
+	if mutedID == userID {
+		err = respondWithError(rw, http.StatusBadRequest, "Cannot mute yourself")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure muted user exists
+	_, err = cfg.db.GetUserByID(rq.Context(), mutedID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "User not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Insert mute into database (muting twice is a no-op)
+	dbParams := database.MuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	}
+
+	err = cfg.db.MuteUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error muting user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) unmuteUserHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	mutedID, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Delete mute from database
+	dbParams := database.UnmuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	}
+
+	_, err = cfg.db.UnmuteUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error unmuting user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+// hiddenUsers holds the users a caller blocked or muted. Real-time feeds
+// can't filter in SQL like GetTimelineChirps does, so they check each event.
+type hiddenUsers map[uuid.UUID]bool
+
+func newHiddenUsers(userIDs []uuid.UUID) hiddenUsers {
+	hidden := make(hiddenUsers, len(userIDs))
+	for _, id := range userIDs {
+		hidden[id] = true
+	}
+	return hidden
+}
+
+// hides reports whether chirp is by a hidden user or rechirps or quotes one.
+func (h hiddenUsers) hides(chirp Chirp) bool {
+	return h[chirp.UserID] || (chirp.Original != nil && h[chirp.Original.UserID])
+}
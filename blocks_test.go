@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Matches the block and mute filters applied to a viewer's chirps, up to the
+// end of the NOT EXISTS subquery.
+var chirpFilterPattern = regexp.MustCompile(`(?s)FROM (blocks|mutes)\s+WHERE (?:blocker|muter)_id = sqlc\.arg\(\w+\)(.*?)\n\s*\)\n`)
+
+func TestChirpFiltersCoverOriginalAuthor(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		query       string
+		wantFilters int
+	}{
+		{"GetChirps", "chirps.sql", "GetChirps", 2},
+		{"GetChirpsByUserId", "chirps.sql", "GetChirpsByUserId", 2},
+		{"GetTimelineChirps", "chirps.sql", "GetTimelineChirps", 2},
+		{"SearchChirpsByRank", "search.sql", "SearchChirpsByRank", 2},
+		{"SearchChirpsByRecency", "search.sql", "SearchChirpsByRecency", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("sql", "queries", tt.file))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+
+			query := ""
+			for _, chunk := range strings.Split(string(data), "-- name: ") {
+				if strings.HasPrefix(chunk, tt.query+" ") {
+					query = chunk
+				}
+			}
+			if len(query) == 0 {
+				t.Fatalf("query %s not found in %s", tt.query, tt.file)
+			}
+
+			// Rechirps and quotes of blocked or muted users are hidden too
+			filters := chirpFilterPattern.FindAllStringSubmatch(query, -1)
+			if len(filters) != tt.wantFilters {
+				t.Fatalf("%s filters = %d, want %d", tt.query, len(filters), tt.wantFilters)
+			}
+			for _, filter := range filters {
+				if !strings.Contains(filter[2], "chirps.user_id") || !strings.Contains(filter[2], "chirps.original_id") {
+					t.Errorf("%s %s filter got = %q, want author and original author", tt.query, filter[1], filter[2])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,565 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxBookmarkCollections       = 100
+	maxBookmarkCollectionNameLen = 50
+)
+
+type bookmarkChirpParams struct {
+	CollectionID *uuid.UUID `json:"collection_id"`
+}
+
+type createBookmarkCollectionParams struct {
+	Name string `json:"name"`
+}
+
+// BookmarkCollection is a named, private group of bookmarks. Only Chirpy Red
+// users can create them.
+type BookmarkCollection struct {
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	Name          string    `json:"name"`
+	BookmarkCount int64     `json:"bookmark_count"`
+}
+
+type bookmarkedChirp struct {
+	Chirp        Chirp
+	BookmarkedAt time.Time
+}
+
+func bookmarkedChirpCursor(bookmarked bookmarkedChirp) pageCursor {
+	return pageCursor{CreatedAt: bookmarked.BookmarkedAt, ID: bookmarked.Chirp.ID}
+}
+
+// cleanCollectionName trims the surrounding whitespace from a collection name
+// and reports whether what's left is an acceptable length.
+func cleanCollectionName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 || utf8.RuneCountInString(name) > maxBookmarkCollectionNameLen {
+		return name, false
+	}
+	return name, true
+}
+
+func (cfg *apiConfig) bookmarkChirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Decode request body (optional)
+	decoder := json.NewDecoder(rq.Body)
+	params := bookmarkChirpParams{}
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure chirp exists
+	dbChirp, err := cfg.db.GetChirpById(rq.Context(), id)
+	if err != nil || dbChirp.DeletedAt.Valid {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure the collection exists and belongs to a Chirpy Red user
+	collectionID := uuid.NullUUID{}
+	if params.CollectionID != nil {
+		dbUser, err := cfg.db.GetUserByID(rq.Context(), userID)
+		if err != nil {
+			err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		if !dbUser.IsChirpyRed {
+			err = respondWithError(rw, http.StatusForbidden, "Collections require Chirpy Red")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		dbCollection, err := cfg.db.GetBookmarkCollectionById(rq.Context(), *params.CollectionID)
+		if err != nil || dbCollection.UserID != userID {
+			err = respondWithError(rw, http.StatusNotFound, "Collection not found")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		collectionID = uuid.NullUUID{UUID: dbCollection.ID, Valid: true}
+	}
+
+	// Insert bookmark into database (bookmarking again moves it to the new collection)
+	dbParams := database.BookmarkChirpParams{
+		UserID:       userID,
+		ChirpID:      id,
+		CollectionID: collectionID,
+	}
+
+	err = cfg.db.BookmarkChirp(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error bookmarking chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) unbookmarkChirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Delete bookmark from database
+	dbParams := database.UnbookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: id,
+	}
+
+	_, err = cfg.db.UnbookmarkChirp(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error removing bookmark")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) getBookmarksHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters (most recently saved first by default)
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if len(rq.URL.Query().Get("sort")) == 0 {
+		page.Sort = "desc"
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Check for collection id query parameter
+	collectionID := uuid.NullUUID{}
+	if collectionParam := rq.URL.Query().Get("collection_id"); len(collectionParam) > 0 {
+		collectionID.UUID, err = uuid.Parse(collectionParam)
+		if err != nil {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid collection ID")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		collectionID.Valid = true
+
+		dbCollection, err := cfg.db.GetBookmarkCollectionById(rq.Context(), collectionID.UUID)
+		if err != nil || dbCollection.UserID != userID {
+			err = respondWithError(rw, http.StatusNotFound, "Collection not found")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	// Get bookmarked chirps from database (deleted chirps are left out)
+	dbParams := database.GetBookmarkedChirpsParams{
+		UserID:          userID,
+		CollectionID:    collectionID,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbRows, err := cfg.db.GetBookmarkedChirps(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting bookmarks")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database rows to bookmarkedChirp struct
+	bookmarked := make([]bookmarkedChirp, len(dbRows))
+	for i, dbRow := range dbRows {
+		bookmarked[i] = bookmarkedChirp{
+			Chirp:        chirpFromDB(dbRow.Chirp),
+			BookmarkedAt: dbRow.BookmarkedAt,
+		}
+	}
+
+	// Trim to the requested page and build cursors (ordered by bookmark time)
+	bookmarked, next, prev := paginate(bookmarked, page, bookmarkedChirpCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	chirps := make([]Chirp, len(bookmarked))
+	for i, bookmark := range bookmarked {
+		chirps[i] = bookmark.Chirp
+	}
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting bookmarks")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return chirps
+	err = respondWithJSON(rw, http.StatusOK, ChirpPage{Chirps: chirps, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) createBookmarkCollectionHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Decode request body
+	decoder := json.NewDecoder(rq.Body)
+	params := createBookmarkCollectionParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not a Chirpy Red user
+	dbUser, err := cfg.db.GetUserByID(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if !dbUser.IsChirpyRed {
+		err = respondWithError(rw, http.StatusForbidden, "Collections require Chirpy Red")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if name is missing or too long
+	name, ok := cleanCollectionName(params.Name)
+	if !ok {
+		err = respondWithError(rw, http.StatusBadRequest, "Name must be 1 to 50 characters")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so concurrent requests can't go over the limit
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating collection")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Lock user so their collections are counted one request at a time
+	_, err = qtx.LockUser(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating collection")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user has too many collections
+	collectionCount, err := qtx.CountBookmarkCollections(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating collection")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if collectionCount >= maxBookmarkCollections {
+		err = respondWithError(rw, http.StatusBadRequest, "Too many collections")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Insert collection into database
+	dbParams := database.CreateBookmarkCollectionParams{
+		UserID: userID,
+		Name:   name,
+	}
+
+	dbCollection, err := qtx.CreateBookmarkCollection(rq.Context(), dbParams)
+	if isUniqueViolation(err) {
+		err = respondWithError(rw, http.StatusConflict, "Collection already exists")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating collection")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return collection
+	collection := BookmarkCollection{
+		ID:        dbCollection.ID,
+		CreatedAt: dbCollection.CreatedAt,
+		Name:      dbCollection.Name,
+	}
+
+	err = respondWithJSON(rw, http.StatusCreated, collection)
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getBookmarkCollectionsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get collections from database
+	dbRows, err := cfg.db.GetBookmarkCollections(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting collections")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database rows to BookmarkCollection struct
+	collections := make([]BookmarkCollection, len(dbRows))
+	for i, dbRow := range dbRows {
+		collections[i] = BookmarkCollection{
+			ID:            dbRow.ID,
+			CreatedAt:     dbRow.CreatedAt,
+			Name:          dbRow.Name,
+			BookmarkCount: dbRow.BookmarkCount,
+		}
+	}
+
+	// Return collections
+	err = respondWithJSON(rw, http.StatusOK, collections)
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) deleteBookmarkCollectionHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get collection ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid collection ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get collection from database (other users' collections are reported as missing)
+	dbCollection, err := cfg.db.GetBookmarkCollectionById(rq.Context(), id)
+	if err != nil || dbCollection.UserID != userID {
+		err = respondWithError(rw, http.StatusNotFound, "Collection not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Delete collection from database (its bookmarks are kept)
+	err = cfg.db.DeleteBookmarkCollection(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting collection")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanCollectionName(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOk bool
+	}{
+		{"PlainName", "Recipes", "Recipes", true},
+		{"TrimsWhitespace", "  Read later \n", "Read later", true},
+		{"EmptyName", "", "", false},
+		{"WhitespaceOnly", "   ", "", false},
+		{"MaximumLength", strings.Repeat("a", maxBookmarkCollectionNameLen), strings.Repeat("a", maxBookmarkCollectionNameLen), true},
+		{"TooLong", strings.Repeat("a", maxBookmarkCollectionNameLen+1), strings.Repeat("a", maxBookmarkCollectionNameLen+1), false},
+		{"CountsCharactersNotBytes", strings.Repeat("é", maxBookmarkCollectionNameLen), strings.Repeat("é", maxBookmarkCollectionNameLen), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cleanCollectionName(tt.input)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("cleanCollectionName() got = %q/%v, want %q/%v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	chirpEventsChannel        = "chirp_events"
+	notificationEventsChannel = "notification_events"
+	chirpEventHistorySize     = 1000
+	subscriberBufferSize      = 64
+)
+
+// ChirpEvent is a chirp that was just created or deleted. IDs increase over
+// time so clients can resume from the last event they saw, on any instance.
+type ChirpEvent struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Chirp Chirp  `json:"chirp"`
+}
+
+type chirpSubscription struct {
+	events chan ChirpEvent
+}
+
+// chirpBroker fans chirp events out to every subscriber and remembers the
+// most recent ones for clients reconnecting with Last-Event-ID. With notify
+// set, events are published through Postgres and delivered back to every
+// server instance by listenForEvents instead of being dispatched here, and
+// nextID hands out event IDs from a sequence every instance shares.
+type chirpBroker struct {
+	mu          sync.Mutex
+	subscribers map[*chirpSubscription]struct{}
+	history     []ChirpEvent
+	historySize int
+	lastID      int64
+	notify      func(ctx context.Context, payload []byte) error
+	nextID      func(ctx context.Context) (int64, error)
+}
+
+func newChirpBroker(historySize int) *chirpBroker {
+	return &chirpBroker{
+		subscribers: make(map[*chirpSubscription]struct{}),
+		historySize: historySize,
+	}
+}
+
+// Publish assigns the event an ID and delivers it to subscribers.
+func (b *chirpBroker) Publish(ctx context.Context, eventType string, chirp Chirp) {
+	// Viewer specific fields make no sense to other subscribers
+	chirp.LikedByMe = nil
+	if chirp.Original != nil {
+		original := *chirp.Original
+		original.LikedByMe = nil
+		chirp.Original = &original
+	}
+
+	event := ChirpEvent{Type: eventType, Chirp: chirp}
+
+	if b.notify == nil {
+		b.dispatch(event)
+		return
+	}
+
+	// The ID travels in the payload so every instance replays the same IDs
+	var err error
+	if b.nextID != nil {
+		event.ID, err = b.nextID(ctx)
+	}
+	var payload []byte
+	if err == nil {
+		payload, err = json.Marshal(event)
+	}
+	if err == nil {
+		err = b.notify(ctx, payload)
+	}
+	if err != nil {
+		log.Printf("Error publishing chirp event: %v", err)
+	}
+}
+
+// dispatch records the event and hands it to every subscriber. Subscribers
+// that can't keep up are dropped and have to reconnect with Last-Event-ID.
+func (b *chirpBroker) dispatch(event ChirpEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Events published without a shared sequence get a local ID, kept
+	// increasing even if the clock goes backwards
+	if event.ID == 0 {
+		event.ID = time.Now().UnixNano()
+		if event.ID <= b.lastID {
+			event.ID = b.lastID + 1
+		}
+	}
+	b.lastID = max(b.lastID, event.ID)
+
+	// NOTIFYs can arrive slightly out of ID order, keep the history sorted
+	i := len(b.history)
+	for i > 0 && b.history[i-1].ID > event.ID {
+		i--
+	}
+	b.history = slices.Insert(b.history, i, event)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber and returns the remembered events
+// after lastEventID. A lastEventID of 0 skips the backlog.
+func (b *chirpBroker) Subscribe(lastEventID int64) (*chirpSubscription, []ChirpEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &chirpSubscription{events: make(chan ChirpEvent, subscriberBufferSize)}
+	b.subscribers[sub] = struct{}{}
+
+	var backlog []ChirpEvent
+	if lastEventID > 0 {
+		for _, event := range b.history {
+			if event.ID > lastEventID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	return sub, backlog
+}
+
+func (b *chirpBroker) Unsubscribe(sub *chirpSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+type notificationSubscription struct {
+	events chan Notification
+}
+
+// notificationEvent is a notification on its way to the recipient's
+// connections, possibly on another server instance.
+type notificationEvent struct {
+	UserID       uuid.UUID    `json:"user_id"`
+	Notification Notification `json:"notification"`
+}
+
+// notificationHub delivers new notifications to their recipient's open
+// connections. Like chirpBroker, it publishes through Postgres when notify
+// is set.
+type notificationHub struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*notificationSubscription]struct{}
+	notify      func(ctx context.Context, payload []byte) error
+}
+
+func newNotificationHub() *notificationHub {
+	return &notificationHub{
+		subscribers: make(map[uuid.UUID]map[*notificationSubscription]struct{}),
+	}
+}
+
+// Publish delivers notifications that were just committed.
+func (h *notificationHub) Publish(ctx context.Context, dbNotifications []database.Notification) {
+	for _, dbNotification := range dbNotifications {
+		event := notificationEvent{UserID: dbNotification.UserID, Notification: notificationFromDB(dbNotification)}
+
+		if h.notify == nil {
+			h.dispatch(event)
+			continue
+		}
+
+		payload, err := json.Marshal(event)
+		if err == nil {
+			err = h.notify(ctx, payload)
+		}
+		if err != nil {
+			log.Printf("Error publishing notification event: %v", err)
+		}
+	}
+}
+
+// dispatch hands the notification to the recipient's subscribers, dropping
+// the ones that can't keep up.
+func (h *notificationHub) dispatch(event notificationEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[event.UserID] {
+		select {
+		case sub.events <- event.Notification:
+		default:
+			h.remove(event.UserID, sub)
+		}
+	}
+}
+
+func (h *notificationHub) Subscribe(userID uuid.UUID) *notificationSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &notificationSubscription{events: make(chan Notification, subscriberBufferSize)}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*notificationSubscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+
+	return sub
+}
+
+func (h *notificationHub) Unsubscribe(userID uuid.UUID, sub *notificationSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(userID, sub)
+}
+
+// remove must be called with h.mu held.
+func (h *notificationHub) remove(userID uuid.UUID, sub *notificationSubscription) {
+	if _, ok := h.subscribers[userID][sub]; !ok {
+		return
+	}
+
+	delete(h.subscribers[userID], sub)
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+	close(sub.events)
+}
+
+// listenForEvents publishes chirp and notification events with NOTIFY and
+// dispatches the ones received with LISTEN, so every server instance sees
+// every event.
+func listenForEvents(dbURL string, db *database.Queries, broker *chirpBroker, hub *notificationHub) error {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Event listener: %v", err)
+		}
+	})
+
+	for _, channel := range []string{chirpEventsChannel, notificationEventsChannel} {
+		err := listener.Listen(channel)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+	}
+
+	broker.notify = func(ctx context.Context, payload []byte) error {
+		return db.NotifyChirpEvent(ctx, string(payload))
+	}
+	broker.nextID = db.NextChirpEventID
+	hub.notify = func(ctx context.Context, payload []byte) error {
+		return db.NotifyNotificationEvent(ctx, string(payload))
+	}
+
+	go func() {
+		for notification := range listener.Notify {
+			// A nil notification means the connection was re-established
+			if notification == nil {
+				continue
+			}
+
+			switch notification.Channel {
+			case chirpEventsChannel:
+				event := ChirpEvent{}
+				err := json.Unmarshal([]byte(notification.Extra), &event)
+				if err != nil {
+					log.Printf("Error decoding chirp event: %v", err)
+					continue
+				}
+				broker.dispatch(event)
+			case notificationEventsChannel:
+				event := notificationEvent{}
+				err := json.Unmarshal([]byte(notification.Extra), &event)
+				if err != nil {
+					log.Printf("Error decoding notification event: %v", err)
+					continue
+				}
+				hub.dispatch(event)
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+)
+
+func TestChirpBrokerSubscribe(t *testing.T) {
+	broker := newChirpBroker(3)
+	for i := 0; i < 5; i++ {
+		broker.dispatch(ChirpEvent{ID: int64(i + 1), Type: "created"})
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID int64
+		wantIDs     []int64
+	}{
+		{"NoLastEventID", 0, nil},
+		{"ResumeFromHistory", 3, []int64{4, 5}},
+		{"ResumeBeforeHistory", 1, []int64{3, 4, 5}},
+		{"UpToDate", 5, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog := broker.Subscribe(tt.lastEventID)
+			defer broker.Unsubscribe(sub)
+
+			if len(backlog) != len(tt.wantIDs) {
+				t.Fatalf("Subscribe() backlog len = %d, want %d", len(backlog), len(tt.wantIDs))
+			}
+			for i, event := range backlog {
+				if event.ID != tt.wantIDs[i] {
+					t.Errorf("Subscribe() backlog[%d] = %d, want %d", i, event.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChirpBrokerPublish(t *testing.T) {
+	broker := newChirpBroker(chirpEventHistorySize)
+	sub, _ := broker.Subscribe(0)
+	slow, _ := broker.Subscribe(0)
+
+	liked := true
+	chirp := Chirp{ID: uuid.New(), LikedByMe: &liked}
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		broker.Publish(context.Background(), "created", chirp)
+		<-sub.events
+	}
+
+	// The slow subscriber never read anything, so it was dropped
+	count := 0
+	for range slow.events {
+		count++
+	}
+	if count != subscriberBufferSize {
+		t.Errorf("slow subscriber got %d events, want %d", count, subscriberBufferSize)
+	}
+
+	broker.Publish(context.Background(), "deleted", chirp)
+	event := <-sub.events
+	if event.Type != "deleted" || event.Chirp.ID != chirp.ID {
+		t.Errorf("Publish() got %v, want deleted event for %v", event, chirp.ID)
+	}
+	if event.Chirp.LikedByMe != nil {
+		t.Errorf("Publish() kept viewer specific liked_by_me")
+	}
+	if event.ID <= broker.history[len(broker.history)-2].ID {
+		t.Errorf("Publish() event IDs are not increasing")
+	}
+}
+
+func TestChirpBrokerSharedIDs(t *testing.T) {
+	broker := newChirpBroker(chirpEventHistorySize)
+
+	var payloads []ChirpEvent
+	broker.notify = func(ctx context.Context, payload []byte) error {
+		event := ChirpEvent{}
+		err := json.Unmarshal(payload, &event)
+		payloads = append(payloads, event)
+		return err
+	}
+	broker.nextID = func(ctx context.Context) (int64, error) {
+		return 7, nil
+	}
+
+	broker.Publish(context.Background(), "created", Chirp{ID: uuid.New()})
+	if len(payloads) != 1 || payloads[0].ID != 7 {
+		t.Fatalf("Publish() payloads = %v, want one event with ID 7", payloads)
+	}
+
+	// Another instance's events arrive out of order and keep their IDs
+	for _, id := range []int64{5, 7, 6} {
+		broker.dispatch(ChirpEvent{ID: id, Type: "created"})
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID int64
+		wantIDs     []int64
+	}{
+		{"ResumeFromStart", 4, []int64{5, 6, 7}},
+		{"ResumeOutOfOrder", 5, []int64{6, 7}},
+		{"UpToDate", 7, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog := broker.Subscribe(tt.lastEventID)
+			defer broker.Unsubscribe(sub)
+
+			if len(backlog) != len(tt.wantIDs) {
+				t.Fatalf("Subscribe() backlog len = %d, want %d", len(backlog), len(tt.wantIDs))
+			}
+			for i, event := range backlog {
+				if event.ID != tt.wantIDs[i] {
+					t.Errorf("Subscribe() backlog[%d] = %d, want %d", i, event.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteChirpEvent(t *testing.T) {
+	id := uuid.New()
+	event := ChirpEvent{ID: 42, Type: "created", Chirp: Chirp{ID: id, Body: "hello"}}
+
+	var sb strings.Builder
+	err := writeChirpEvent(&sb, event)
+	if err != nil {
+		t.Fatalf("writeChirpEvent() error = %v", err)
+	}
+
+	got := sb.String()
+	if !strings.HasPrefix(got, "id: 42\nevent: created\ndata: {") || !strings.HasSuffix(got, "}\n\n") {
+		t.Errorf("writeChirpEvent() = %q", got)
+	}
+	if !strings.Contains(got, id.String()) || strings.Count(got, "\n") != 4 {
+		t.Errorf("writeChirpEvent() = %q", got)
+	}
+}
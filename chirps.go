@@ -1,25 +1,237 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
 	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
 	"github.com/google/uuid"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
 type createChirpParams struct {
+	Body      string            `json:"body"`
+	InReplyTo *uuid.UUID        `json:"in_reply_to"`
+	QuoteOf   *uuid.UUID        `json:"quote_of"`
+	MediaIDs  []uuid.UUID       `json:"media_ids"`
+	Poll      *createPollParams `json:"poll"`
+}
+
+type updateChirpParams struct {
 	Body string `json:"body"`
 }
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	UserID     uuid.UUID  `json:"user_id"`
+	InReplyTo  *uuid.UUID `json:"in_reply_to,omitempty"`
+	ThreadID   uuid.UUID  `json:"thread_id"`
+	ReplyCount int64      `json:"reply_count"`
+	LikeCount  int64      `json:"like_count"`
+	LikedByMe  *bool      `json:"liked_by_me,omitempty"`
+	Kind       string     `json:"kind"`
+	OriginalID *uuid.UUID `json:"original_id,omitempty"`
+	Original   *Chirp     `json:"original,omitempty"`
+	Deleted    bool       `json:"deleted,omitempty"`
+	Media      []Media    `json:"media,omitempty"`
+	Poll       *Poll      `json:"poll,omitempty"`
+	Pinned     bool       `json:"pinned,omitempty"`
+}
+
+// Map database chirp to Chirp struct
+func chirpFromDB(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:        dbChirp.ID,
+		CreatedAt: dbChirp.CreatedAt,
+		UpdatedAt: dbChirp.UpdatedAt,
+		Body:      dbChirp.Body,
+		UserID:    dbChirp.UserID,
+		ThreadID:  dbChirp.ID,
+		Kind:      dbChirp.Kind,
+		Deleted:   dbChirp.DeletedAt.Valid,
+	}
+
+	if dbChirp.ParentID.Valid {
+		chirp.InReplyTo = &dbChirp.ParentID.UUID
+	}
+
+	if dbChirp.OriginalID.Valid {
+		chirp.OriginalID = &dbChirp.OriginalID.UUID
+	}
+
+	// Root chirps have no thread ID of their own
+	if dbChirp.ThreadID.Valid {
+		chirp.ThreadID = dbChirp.ThreadID.UUID
+	}
+
+	return chirp
+}
+
+// removeChirp deletes a chirp the caller has locked. Chirps that others
+// reply to, rechirp or quote are left as tombstones so threads stay
+// connected; the rest are deleted along with tombstones only they kept
+// around. It returns the media deleted with them, whose files should be
+// removed once the transaction commits.
+func removeChirp(ctx context.Context, qtx *database.Queries, dbChirp database.Chirp) ([]database.Medium, error) {
+	id := uuid.NullUUID{UUID: dbChirp.ID, Valid: true}
+
+	// Check whether other chirps reply to, rechirp or quote this one
+	isReferenced, err := qtx.ChirpIsReferenced(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unpin chirp so it drops off the author's profile
+	err = qtx.DeleteChirpPin(ctx, dbChirp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete attached media (tombstones hide it anyway)
+	deletedMedia, err := qtx.DeleteChirpMedia(ctx, dbChirp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isReferenced {
+		// Leave a tombstone so threads, rechirps and quotes stay connected
+		err = qtx.TombstoneChirp(ctx, dbChirp.ID)
+		if err == nil {
+			err = qtx.DeleteChirpRevisions(ctx, dbChirp.ID)
+		}
+		if err == nil {
+			err = qtx.DeleteChirpHashtags(ctx, dbChirp.ID)
+		}
+		if err == nil {
+			err = qtx.DeleteChirpMentions(ctx, dbChirp.ID)
+		}
+		if err == nil {
+			err = qtx.DeleteChirpBookmarks(ctx, dbChirp.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return deletedMedia, nil
+	}
+
+	// Delete chirp from database, along with tombstones only it kept around
+	err = qtx.DeleteChirp(ctx, dbChirp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	prunedMedia, err := pruneTombstones(ctx, qtx, dbChirp.ParentID, dbChirp.OriginalID)
+	if err != nil {
+		return nil, err
+	}
+	return append(deletedMedia, prunedMedia...), nil
+}
+
+// pruneTombstones hard-deletes tombstoned chirps that nothing replies to,
+// rechirps or quotes anymore. Removing one can free its own parent or
+// original, so it keeps walking up until it reaches a live chirp. It returns
+// the media deleted along with them, whose files should be removed once the
+// transaction commits.
+func pruneTombstones(ctx context.Context, qtx *database.Queries, ids ...uuid.NullUUID) ([]database.Medium, error) {
+	var deletedMedia []database.Medium
+	for len(ids) > 0 {
+		id := ids[0]
+		ids = ids[1:]
+		if !id.Valid {
+			continue
+		}
+
+		dbChirp, err := qtx.GetChirpByIdForUpdate(ctx, id.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !dbChirp.DeletedAt.Valid {
+			continue
+		}
+
+		isReferenced, err := qtx.ChirpIsReferenced(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if isReferenced {
+			continue
+		}
+
+		dbMedia, err := qtx.DeleteChirpMedia(ctx, id.UUID)
+		if err != nil {
+			return nil, err
+		}
+		deletedMedia = append(deletedMedia, dbMedia...)
+
+		err = qtx.DeleteChirp(ctx, id.UUID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, dbChirp.ParentID, dbChirp.OriginalID)
+	}
+
+	return deletedMedia, nil
+}
+
+// insertChirp saves a new chirp with its hashtags and mentions and notifies
+// the authors of the parent and quoted chirps, returning the notifications to
+// publish once the transaction commits.
+func insertChirp(ctx context.Context, qtx *database.Queries, dbParams database.CreateChirpParams, parentAuthorID, quotedAuthorID uuid.UUID) (database.Chirp, []database.Notification, error) {
+	dbChirp, err := qtx.CreateChirp(ctx, dbParams)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	// Insert hashtags into database
+	tags := extractHashtags(dbChirp.Body)
+	if len(tags) > 0 {
+		tagParams := database.CreateChirpHashtagsParams{
+			ChirpID: dbChirp.ID,
+			Tags:    tags,
+		}
+
+		err = qtx.CreateChirpHashtags(ctx, tagParams)
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+	}
+
+	// Insert mentions and notify mentioned users
+	notifications, err := saveChirpMentions(ctx, qtx, dbChirp)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	// Notify the authors of the parent and quoted chirps
+	chirpID := uuid.NullUUID{UUID: dbChirp.ID, Valid: true}
+	if dbChirp.ParentID.Valid {
+		created, err := createNotification(ctx, qtx, parentAuthorID, dbChirp.UserID, "reply", chirpID)
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+		notifications = append(notifications, created...)
+	}
+	if dbChirp.OriginalID.Valid {
+		created, err := createNotification(ctx, qtx, quotedAuthorID, dbChirp.UserID, "quote", chirpID)
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+		notifications = append(notifications, created...)
+	}
+
+	return dbChirp, notifications, nil
 }
 
 func (cfg *apiConfig) createChirpHandler(rw http.ResponseWriter, rq *http.Request) {
@@ -46,7 +258,7 @@ func (cfg *apiConfig) createChirpHandler(rw http.ResponseWriter, rq *http.Reques
 	}
 
 	// Validate JWT
-	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	userID, err := cfg.validateJWT(rq.Context(), token)
 	if err != nil {
 		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
 		if err != nil {
@@ -64,13 +276,131 @@ func (cfg *apiConfig) createChirpHandler(rw http.ResponseWriter, rq *http.Reques
 		return
 	}
 
+	// Return error if there are too many or repeated media attachments
+	if len(params.MediaIDs) > maxChirpMedia {
+		err = respondWithError(rw, http.StatusBadRequest, fmt.Sprintf("A chirp can have at most %d media attachments", maxChirpMedia))
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	seenMedia := make(map[uuid.UUID]bool, len(params.MediaIDs))
+	for _, mediaID := range params.MediaIDs {
+		if seenMedia[mediaID] {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid media ID")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		seenMedia[mediaID] = true
+	}
+
+	// Return error if the poll is not valid
+	if params.Poll != nil {
+		if msg := params.Poll.validate(time.Now().UTC()); len(msg) > 0 {
+			err = respondWithError(rw, http.StatusBadRequest, msg)
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
 	// Insert chirp into database
 	dbParams := database.CreateChirpParams{
 		Body:   replaceBadWords(params.Body),
 		UserID: userID,
+		Kind:   "chirp",
+	}
+
+	// Authors to notify once the chirp is saved
+	var parentAuthorID, quotedAuthorID uuid.UUID
+
+	// Attach reply to its parent and the parent's thread
+	if params.InReplyTo != nil {
+		parent, err := cfg.db.GetChirpById(rq.Context(), *params.InReplyTo)
+		if err != nil || parent.DeletedAt.Valid {
+			err = respondWithError(rw, http.StatusNotFound, "Parent chirp not found")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		// Return error if the parent author blocked the user
+		blockParams := database.IsBlockedParams{
+			BlockerID: parent.UserID,
+			BlockedID: userID,
+		}
+
+		blocked, err := cfg.db.IsBlocked(rq.Context(), blockParams)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error creating chirp")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		if blocked {
+			err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		dbParams.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		dbParams.ThreadID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		if parent.ThreadID.Valid {
+			dbParams.ThreadID = parent.ThreadID
+		}
+		parentAuthorID = parent.UserID
+	}
+
+	// Embed the quoted chirp
+	if params.QuoteOf != nil {
+		original, err := cfg.db.GetChirpById(rq.Context(), *params.QuoteOf)
+		if err != nil || original.DeletedAt.Valid {
+			err = respondWithError(rw, http.StatusNotFound, "Quoted chirp not found")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		// Quoting a rechirp quotes the chirp it points at
+		if original.Kind == "rechirp" && original.OriginalID.Valid {
+			original, err = cfg.db.GetChirpById(rq.Context(), original.OriginalID.UUID)
+			if err != nil || original.DeletedAt.Valid {
+				err = respondWithError(rw, http.StatusNotFound, "Quoted chirp not found")
+				if err != nil {
+					log.Printf("Error responding: %v", err)
+				}
+				return
+			}
+		}
+
+		dbParams.Kind = "quote"
+		dbParams.OriginalID = uuid.NullUUID{UUID: original.ID, Valid: true}
+		quotedAuthorID = original.UserID
+	}
+
+	// Start transaction so the chirp, its hashtags, mentions and notifications are saved together
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
 
-	dbChirp, err := cfg.db.CreateChirp(rq.Context(), dbParams)
+	// Insert chirp, hashtags and mentions into database
+	dbChirp, notifications, err := insertChirp(rq.Context(), qtx, dbParams, parentAuthorID, quotedAuthorID)
 	if err != nil {
 		err = respondWithError(rw, http.StatusInternalServerError, "Error creating chirp")
 		if err != nil {
@@ -79,41 +409,138 @@ func (cfg *apiConfig) createChirpHandler(rw http.ResponseWriter, rq *http.Reques
 		return
 	}
 
+	// Attach media the user uploaded that isn't on another chirp yet
+	if len(params.MediaIDs) > 0 {
+		countParams := database.CountAttachableMediaParams{
+			Ids:    params.MediaIDs,
+			UserID: userID,
+		}
+
+		mediaCount, err := qtx.CountAttachableMedia(rq.Context(), countParams)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error creating chirp")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		if mediaCount != int64(len(params.MediaIDs)) {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid media ID")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		attachParams := database.AttachChirpMediaParams{
+			ChirpID:  dbChirp.ID,
+			MediaIds: params.MediaIDs,
+		}
+
+		err = qtx.AttachChirpMedia(rq.Context(), attachParams)
+		if isUniqueViolation(err) {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid media ID")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error creating chirp")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	// Insert poll and its options into database
+	if params.Poll != nil {
+		pollParams := database.CreatePollParams{
+			ChirpID:  dbChirp.ID,
+			ClosesAt: params.Poll.ClosesAt.UTC(),
+		}
+
+		err = qtx.CreatePoll(rq.Context(), pollParams)
+		if err == nil {
+			labels := make([]string, len(params.Poll.Options))
+			for i, option := range params.Poll.Options {
+				labels[i] = strings.TrimSpace(option)
+			}
+
+			optionParams := database.CreatePollOptionsParams{
+				ChirpID: dbChirp.ID,
+				Labels:  labels,
+			}
+			err = qtx.CreatePollOptions(rq.Context(), optionParams)
+		}
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error creating chirp")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Push notifications to connected clients
+	cfg.notificationHub.Publish(rq.Context(), notifications)
+
 	// Map database chirp to Chirp struct
-	chirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+
+	// Embed the quoted chirp
+	err = cfg.hydrateChirps(rq.Context(), chirps, userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
 	}
 
+	// Push chirp to stream subscribers
+	cfg.broker.Publish(rq.Context(), "created", chirps[0])
+
 	// Return chirp
-	err = respondWithJSON(rw, http.StatusCreated, chirp)
+	err = respondWithJSON(rw, http.StatusCreated, chirps[0])
 	if err != nil {
 		log.Printf("Error responding: %v", err)
 	}
 }
 
 func (cfg *apiConfig) getChirpsHandler(rw http.ResponseWriter, rq *http.Request) {
-	// Check for sort parameter
-	sortParam := rq.URL.Query().Get("sort")
-	if len(sortParam) == 0 {
-		sortParam = "asc"
-	}
-
-	if sortParam != "asc" && sortParam != "desc" {
-		err := respondWithError(rw, http.StatusBadRequest, "Invalid sort parameter")
+	// Check for sort, limit and cursor parameters
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
 		if err != nil {
 			log.Printf("Error responding: %v", err)
 		}
 		return
 	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Signed in callers don't see chirps by users they blocked or muted
+	viewerID := cfg.getOptionalUserID(rq)
+
+	var dbChirps []database.Chirp
+	var authorID uuid.UUID
 
 	// Check for author id query parameter
 	authorParam := rq.URL.Query().Get("author_id")
 	if len(authorParam) > 0 {
-		authorID, err := uuid.Parse(authorParam)
+		authorID, err = uuid.Parse(authorParam)
 		if err != nil {
 			err = respondWithError(rw, http.StatusBadRequest, "Invalid author ID")
 			if err != nil {
@@ -124,10 +551,48 @@ func (cfg *apiConfig) getChirpsHandler(rw http.ResponseWriter, rq *http.Request)
 
 		// Get chirps by author from database
 		dbParams := database.GetChirpsByUserIdParams{
-			UserID:  authorID,
-			Column2: sortParam,
+			UserID:          authorID,
+			ViewerID:        viewerID,
+			CursorCreatedAt: cursorCreatedAt,
+			Sort:            page.queryOrder(),
+			CursorID:        cursorID,
+			PageLimit:       page.queryLimit(),
+		}
+		dbChirps, err = cfg.db.GetChirpsByUserId(rq.Context(), dbParams)
+	} else {
+		// Get chirps from database
+		dbParams := database.GetChirpsParams{
+			ViewerID:        viewerID,
+			CursorCreatedAt: cursorCreatedAt,
+			Sort:            page.queryOrder(),
+			CursorID:        cursorID,
+			PageLimit:       page.queryLimit(),
 		}
-		dbChirps, err := cfg.db.GetChirpsByUserId(rq.Context(), dbParams)
+		dbChirps, err = cfg.db.GetChirps(rq.Context(), dbParams)
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirps")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database chirps to Chirp struct
+	chirps := make([]Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = chirpFromDB(dbChirp)
+	}
+
+	// Trim to the requested page and build cursors
+	chirps, next, prev := paginate(chirps, page, chirpCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Pinned chirps lead the first page and are left out of the rest
+	if authorID != uuid.Nil {
+		chirps, err = pinFirstPage(chirps, page, prev, func() ([]Chirp, error) {
+			return cfg.getPinnedChirps(rq.Context(), authorID, viewerID)
+		})
 		if err != nil {
 			err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirps")
 			if err != nil {
@@ -135,57 +600,67 @@ func (cfg *apiConfig) getChirpsHandler(rw http.ResponseWriter, rq *http.Request)
 			}
 			return
 		}
+	}
 
-		// Map database chirps to Chirp struct
-		chirps := make([]Chirp, len(dbChirps))
-		for i, dbChirp := range dbChirps {
-			chirps[i] = Chirp{
-				ID:        dbChirp.ID,
-				CreatedAt: dbChirp.CreatedAt,
-				UpdatedAt: dbChirp.UpdatedAt,
-				Body:      dbChirp.Body,
-				UserID:    dbChirp.UserID,
-			}
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, viewerID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirps")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
 		}
+		return
+	}
+
+	// Return chirps
+	err = respondWithJSON(rw, http.StatusOK, ChirpPage{Chirps: chirps, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
 
-		// Return chirps
-		err = respondWithJSON(rw, http.StatusOK, chirps)
+func (cfg *apiConfig) getChirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
 		if err != nil {
 			log.Printf("Error responding: %v", err)
 		}
 		return
 	}
 
-	// Get chirps from database
-	dbChirps, err := cfg.db.GetChirps(rq.Context(), sortParam)
-	if err != nil {
-		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirps")
+	// Get chirp from database
+	dbChirp, err := cfg.db.GetChirpById(rq.Context(), id)
+	if err != nil || dbChirp.DeletedAt.Valid {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
 		if err != nil {
 			log.Printf("Error responding: %v", err)
 		}
 		return
 	}
 
-	// Map database chirps to Chirp struct
-	chirps := make([]Chirp, len(dbChirps))
-	for i, dbChirp := range dbChirps {
-		chirps[i] = Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			Body:      dbChirp.Body,
-			UserID:    dbChirp.UserID,
+	// Map database chirp to Chirp struct
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, cfg.getOptionalUserID(rq))
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
 		}
+		return
 	}
 
-	// Return chirps
-	err = respondWithJSON(rw, http.StatusOK, chirps)
+	// Return chirp
+	err = respondWithJSON(rw, http.StatusOK, chirps[0])
 	if err != nil {
 		log.Printf("Error responding: %v", err)
 	}
 }
 
-func (cfg *apiConfig) getChirpHandler(rw http.ResponseWriter, rq *http.Request) {
+func (cfg *apiConfig) updateChirpHandler(rw http.ResponseWriter, rq *http.Request) {
 	// Get chirp ID from URL
 	id, err := uuid.Parse(rq.PathValue("id"))
 	if err != nil {
@@ -196,9 +671,62 @@ func (cfg *apiConfig) getChirpHandler(rw http.ResponseWriter, rq *http.Request)
 		return
 	}
 
-	// Get chirp from database
-	dbChirp, err := cfg.db.GetChirpById(rq.Context(), id)
+	// Decode request body
+	decoder := json.NewDecoder(rq.Body)
+	params := updateChirpParams{}
+	err = decoder.Decode(&params)
 	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if chirp longer than 140 characters
+	if len(params.Body) > 140 {
+		err = respondWithError(rw, http.StatusBadRequest, "Chirp is too long")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the revision and the edit are saved together
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Get and lock chirp from database
+	dbChirp, err := qtx.GetChirpByIdForUpdate(rq.Context(), id)
+	if err != nil || dbChirp.DeletedAt.Valid {
 		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
 		if err != nil {
 			log.Printf("Error responding: %v", err)
@@ -206,17 +734,118 @@ func (cfg *apiConfig) getChirpHandler(rw http.ResponseWriter, rq *http.Request)
 		return
 	}
 
+	// Return error if user is not chirp owner
+	if dbChirp.UserID != userID {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Rechirps have no body of their own
+	if dbChirp.Kind == "rechirp" {
+		err = respondWithError(rw, http.StatusBadRequest, "Rechirps cannot be edited")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Store the current body as a revision
+	revisionParams := database.CreateChirpRevisionParams{
+		ChirpID: dbChirp.ID,
+		Body:    dbChirp.Body,
+	}
+
+	_, err = qtx.CreateChirpRevision(rq.Context(), revisionParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Update chirp in database
+	dbParams := database.UpdateChirpBodyParams{
+		Body: replaceBadWords(params.Body),
+		ID:   dbChirp.ID,
+	}
+
+	dbChirp, err = qtx.UpdateChirpBody(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Replace hashtags with the ones in the new body
+	err = qtx.DeleteChirpHashtags(rq.Context(), dbChirp.ID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	tags := extractHashtags(dbChirp.Body)
+	if len(tags) > 0 {
+		tagParams := database.CreateChirpHashtagsParams{
+			ChirpID: dbChirp.ID,
+			Tags:    tags,
+		}
+
+		err = qtx.CreateChirpHashtags(rq.Context(), tagParams)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error updating chirp")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	// Replace mentions and notify newly mentioned users
+	notifications, err := saveChirpMentions(rq.Context(), qtx, dbChirp)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Push notifications to connected clients
+	cfg.notificationHub.Publish(rq.Context(), notifications)
+
 	// Map database chirp to Chirp struct
-	chirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
 	}
 
 	// Return chirp
-	err = respondWithJSON(rw, http.StatusOK, chirp)
+	err = respondWithJSON(rw, http.StatusOK, chirps[0])
 	if err != nil {
 		log.Printf("Error responding: %v", err)
 	}
@@ -244,7 +873,7 @@ func (cfg *apiConfig) deleteChirpHandler(rw http.ResponseWriter, rq *http.Reques
 	}
 
 	// Validate JWT
-	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	userID, err := cfg.validateJWT(rq.Context(), token)
 	if err != nil {
 		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
 		if err != nil {
@@ -255,7 +884,7 @@ func (cfg *apiConfig) deleteChirpHandler(rw http.ResponseWriter, rq *http.Reques
 
 	// Get chirp from database
 	dbChirp, err := cfg.db.GetChirpById(rq.Context(), id)
-	if err != nil {
+	if err != nil || dbChirp.DeletedAt.Valid {
 		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
 		if err != nil {
 			log.Printf("Error responding: %v", err)
@@ -272,8 +901,8 @@ func (cfg *apiConfig) deleteChirpHandler(rw http.ResponseWriter, rq *http.Reques
 		return
 	}
 
-	// Delete chirp from database
-	err = cfg.db.DeleteChirp(rq.Context(), id)
+	// Start transaction so replies can't sneak in between the check and the delete
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
 	if err != nil {
 		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting chirp")
 		if err != nil {
@@ -281,6 +910,55 @@ func (cfg *apiConfig) deleteChirpHandler(rw http.ResponseWriter, rq *http.Reques
 		}
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Lock chirp so no new replies are attached while deleting
+	dbChirp, err = qtx.GetChirpByIdForUpdate(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if a concurrent request deleted the chirp first
+	if dbChirp.DeletedAt.Valid {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Tombstone or delete chirp
+	deletedMedia, err := removeChirp(rq.Context(), qtx, dbChirp)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Remove media files now that nothing references them
+	cfg.deleteMediaFiles(rq.Context(), deletedMedia)
+
+	// Push deletion to stream subscribers
+	deleted := chirpFromDB(dbChirp)
+	deleted.Body = ""
+	deleted.Deleted = true
+	cfg.broker.Publish(rq.Context(), "deleted", deleted)
 
 	// Return success (no content)
 	respondWithNoContent(rw)
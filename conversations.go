@@ -0,0 +1,833 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	maxConversationParticipants = 10
+	maxMessageLength            = 1000
+)
+
+type createConversationParams struct {
+	ParticipantIDs []uuid.UUID `json:"participant_ids"`
+}
+
+type createMessageParams struct {
+	Body string `json:"body"`
+}
+
+type Participant struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+}
+
+type Conversation struct {
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Participants []Participant `json:"participants"`
+	UnreadCount  int64         `json:"unread_count"`
+}
+
+type ConversationPage struct {
+	Conversations []Conversation `json:"conversations"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+	PrevCursor    string         `json:"prev_cursor,omitempty"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
+}
+
+// Map database conversation to Conversation struct
+func conversationFromDB(dbConversation database.Conversation) Conversation {
+	return Conversation{
+		ID:           dbConversation.ID,
+		CreatedAt:    dbConversation.CreatedAt,
+		UpdatedAt:    dbConversation.UpdatedAt,
+		Participants: []Participant{},
+	}
+}
+
+// Map database message to Message struct
+func messageFromDB(dbMessage database.Message) Message {
+	return Message{
+		ID:             dbMessage.ID,
+		CreatedAt:      dbMessage.CreatedAt,
+		ConversationID: dbMessage.ConversationID,
+		SenderID:       dbMessage.SenderID,
+		Body:           dbMessage.Body,
+	}
+}
+
+// Conversations are listed by their latest activity
+func conversationCursor(conversation Conversation) pageCursor {
+	return pageCursor{CreatedAt: conversation.UpdatedAt, ID: conversation.ID}
+}
+
+func messageCursor(message Message) pageCursor {
+	return pageCursor{CreatedAt: message.CreatedAt, ID: message.ID}
+}
+
+// otherParticipantIDs returns the distinct requested participants other than
+// userID, in the order they were requested.
+func otherParticipantIDs(userID uuid.UUID, requested []uuid.UUID) []uuid.UUID {
+	otherIDs := make([]uuid.UUID, 0, len(requested))
+	seen := map[uuid.UUID]bool{userID: true}
+	for _, id := range requested {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		otherIDs = append(otherIDs, id)
+	}
+	return otherIDs
+}
+
+// directPair orders the two users of a one-to-one conversation the way
+// Postgres orders UUIDs, so either of them starting it finds the same row.
+func directPair(a, b uuid.UUID) (low, high uuid.UUID) {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		return b, a
+	}
+	return a, b
+}
+
+// addParticipants fills in the participants and their read receipts.
+func (cfg *apiConfig) addParticipants(ctx context.Context, conversations []Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(conversations))
+	index := make(map[uuid.UUID]int, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+		index[conversation.ID] = i
+	}
+
+	dbParticipants, err := cfg.db.GetConversationParticipants(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, dbParticipant := range dbParticipants {
+		participant := Participant{
+			UserID:   dbParticipant.UserID,
+			JoinedAt: dbParticipant.JoinedAt,
+		}
+		if dbParticipant.LastReadAt.Valid {
+			participant.LastReadAt = &dbParticipant.LastReadAt.Time
+		}
+
+		i := index[dbParticipant.ConversationID]
+		conversations[i].Participants = append(conversations[i].Participants, participant)
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) createConversationHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Decode request body
+	decoder := json.NewDecoder(rq.Body)
+	params := createConversationParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Collect the other participants (the caller is always included)
+	otherIDs := otherParticipantIDs(userID, params.ParticipantIDs)
+
+	// Return error if there is nobody to talk to, or too many people
+	if len(otherIDs) == 0 {
+		err = respondWithError(rw, http.StatusBadRequest, "At least one other participant is required")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if len(otherIDs)+1 > maxConversationParticipants {
+		err = respondWithError(rw, http.StatusBadRequest, "Too many participants")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure every participant exists
+	userCount, err := cfg.db.CountUsersByIds(rq.Context(), otherIDs)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if userCount != int64(len(otherIDs)) {
+		err = respondWithError(rw, http.StatusNotFound, "User not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if any two participants blocked each other (including the user)
+	participantIDs := append([]uuid.UUID{userID}, otherIDs...)
+	blocked, err := cfg.db.HasBlocksBetween(rq.Context(), participantIDs)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if blocked {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the conversation and its participants are saved together
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	status := http.StatusCreated
+	var dbConversation database.Conversation
+
+	if len(otherIDs) == 1 {
+		// One-to-one conversations are unique per pair, reuse the existing one
+		low, high := directPair(userID, otherIDs[0])
+		directParams := database.CreateDirectConversationParams{
+			CreatedBy:      uuid.NullUUID{UUID: userID, Valid: true},
+			DirectUserLow:  uuid.NullUUID{UUID: low, Valid: true},
+			DirectUserHigh: uuid.NullUUID{UUID: high, Valid: true},
+		}
+
+		dbConversation, err = qtx.CreateDirectConversation(rq.Context(), directParams)
+		if errors.Is(err, sql.ErrNoRows) {
+			status = http.StatusOK
+			existingParams := database.GetDirectConversationParams{
+				DirectUserLow:  directParams.DirectUserLow,
+				DirectUserHigh: directParams.DirectUserHigh,
+			}
+			dbConversation, err = qtx.GetDirectConversation(rq.Context(), existingParams)
+		}
+	} else {
+		dbConversation, err = qtx.CreateConversation(rq.Context(), uuid.NullUUID{UUID: userID, Valid: true})
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if status == http.StatusCreated {
+		participantParams := database.AddConversationParticipantsParams{
+			ConversationID: dbConversation.ID,
+			UserIds:        participantIDs,
+		}
+
+		err = qtx.AddConversationParticipants(rq.Context(), participantParams)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error creating conversation")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database conversation to Conversation struct
+	conversations := []Conversation{conversationFromDB(dbConversation)}
+
+	// Add participants
+	err = cfg.addParticipants(rq.Context(), conversations)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return conversation
+	err = respondWithJSON(rw, status, conversations[0])
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getConversationsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters (most recently active first by default)
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if len(rq.URL.Query().Get("sort")) == 0 {
+		page.Sort = "desc"
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Get conversations from database
+	dbParams := database.GetConversationsForUserParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbRows, err := cfg.db.GetConversationsForUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting conversations")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database conversations to Conversation struct
+	conversations := make([]Conversation, len(dbRows))
+	for i, dbRow := range dbRows {
+		conversations[i] = conversationFromDB(dbRow.Conversation)
+		conversations[i].UnreadCount = dbRow.UnreadCount
+	}
+
+	// Trim to the requested page and build cursors
+	conversations, next, prev := paginate(conversations, page, conversationCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Add participants
+	err = cfg.addParticipants(rq.Context(), conversations)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting conversations")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return conversations
+	err = respondWithJSON(rw, http.StatusOK, ConversationPage{Conversations: conversations, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getConversationHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get conversation ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid conversation ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not a participant
+	participantParams := database.IsConversationParticipantParams{
+		ConversationID: id,
+		UserID:         userID,
+	}
+
+	isParticipant, err := cfg.db.IsConversationParticipant(rq.Context(), participantParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if !isParticipant {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get conversation from database
+	dbConversation, err := cfg.db.GetConversationById(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "Conversation not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database conversation to Conversation struct
+	conversations := []Conversation{conversationFromDB(dbConversation)}
+
+	// Add participants and their read receipts
+	err = cfg.addParticipants(rq.Context(), conversations)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return conversation
+	err = respondWithJSON(rw, http.StatusOK, conversations[0])
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) createMessageHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get conversation ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid conversation ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Decode request body
+	decoder := json.NewDecoder(rq.Body)
+	params := createMessageParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if message is empty or too long
+	if len(params.Body) == 0 {
+		err = respondWithError(rw, http.StatusBadRequest, "Message body is required")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if len(params.Body) > maxMessageLength {
+		err = respondWithError(rw, http.StatusBadRequest, "Message is too long")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not a participant
+	participantParams := database.IsConversationParticipantParams{
+		ConversationID: id,
+		UserID:         userID,
+	}
+
+	isParticipant, err := cfg.db.IsConversationParticipant(rq.Context(), participantParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating message")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if !isParticipant {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if another participant blocked the user
+	blockParams := database.IsBlockedInConversationParams{
+		ConversationID: id,
+		BlockedID:      userID,
+	}
+
+	blocked, err := cfg.db.IsBlockedInConversation(rq.Context(), blockParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating message")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if blocked {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the message and the conversation activity are saved together
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating message")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Insert message into database
+	dbParams := database.CreateMessageParams{
+		ConversationID: id,
+		SenderID:       userID,
+		Body:           params.Body,
+	}
+
+	dbMessage, err := qtx.CreateMessage(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating message")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Move conversation to the top and mark it read for the sender
+	err = qtx.TouchConversation(rq.Context(), id)
+	if err == nil {
+		err = qtx.MarkConversationRead(rq.Context(), database.MarkConversationReadParams{ConversationID: id, UserID: userID})
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating message")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating message")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return message
+	err = respondWithJSON(rw, http.StatusCreated, messageFromDB(dbMessage))
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getMessagesHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get conversation ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid conversation ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters (newest first by default)
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if len(rq.URL.Query().Get("sort")) == 0 {
+		page.Sort = "desc"
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Return error if user is not a participant
+	participantParams := database.IsConversationParticipantParams{
+		ConversationID: id,
+		UserID:         userID,
+	}
+
+	isParticipant, err := cfg.db.IsConversationParticipant(rq.Context(), participantParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting messages")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if !isParticipant {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get messages from database
+	dbParams := database.GetMessagesParams{
+		ConversationID:  id,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbMessages, err := cfg.db.GetMessages(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting messages")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database messages to Message struct
+	messages := make([]Message, len(dbMessages))
+	for i, dbMessage := range dbMessages {
+		messages[i] = messageFromDB(dbMessage)
+	}
+
+	// Trim to the requested page and build cursors
+	messages, next, prev := paginate(messages, page, messageCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Return messages
+	err = respondWithJSON(rw, http.StatusOK, MessagePage{Messages: messages, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) markConversationReadHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get conversation ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid conversation ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not a participant
+	participantParams := database.IsConversationParticipantParams{
+		ConversationID: id,
+		UserID:         userID,
+	}
+
+	isParticipant, err := cfg.db.IsConversationParticipant(rq.Context(), participantParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if !isParticipant {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Record read receipt
+	dbParams := database.MarkConversationReadParams{
+		ConversationID: id,
+		UserID:         userID,
+	}
+
+	err = cfg.db.MarkConversationRead(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating conversation")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
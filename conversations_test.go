@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"slices"
+	"testing"
+)
+
+func TestOtherParticipantIDs(t *testing.T) {
+	userID := uuid.New()
+	a, b := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		requested []uuid.UUID
+		want      []uuid.UUID
+	}{
+		{"SingleParticipant", []uuid.UUID{a}, []uuid.UUID{a}},
+		{"KeepsRequestOrder", []uuid.UUID{b, a}, []uuid.UUID{b, a}},
+		{"DropsDuplicates", []uuid.UUID{a, b, a}, []uuid.UUID{a, b}},
+		{"DropsCaller", []uuid.UUID{userID, a}, []uuid.UUID{a}},
+		{"OnlyCaller", []uuid.UUID{userID}, []uuid.UUID{}},
+		{"NoParticipants", nil, []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := otherParticipantIDs(userID, tt.requested)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("otherParticipantIDs() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirectPair(t *testing.T) {
+	low := uuid.MustParse("0a000000-0000-0000-0000-000000000000")
+	high := uuid.MustParse("f0000000-0000-0000-0000-000000000000")
+	// Only the last byte differs
+	lowLastByte := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	highLastByte := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+
+	tests := []struct {
+		name     string
+		a        uuid.UUID
+		b        uuid.UUID
+		wantLow  uuid.UUID
+		wantHigh uuid.UUID
+	}{
+		{"AlreadyOrdered", low, high, low, high},
+		{"Reversed", high, low, low, high},
+		{"LastByte", highLastByte, lowLastByte, lowLastByte, highLastByte},
+		{"SameUser", low, low, low, low},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotLow, gotHigh := directPair(tt.a, tt.b)
+			if gotLow != tt.wantLow || gotHigh != tt.wantHigh {
+				t.Errorf("directPair() got = %v/%v, want %v/%v", gotLow, gotHigh, tt.wantLow, tt.wantHigh)
+			}
+		})
+	}
+}
@@ -0,0 +1,820 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	maxScheduleAhead  = 365 * 24 * time.Hour
+	schedulerInterval = 10 * time.Second
+
+	// Drafts that keep failing to publish are retried with a growing delay
+	// and unscheduled after the last attempt
+	maxDraftPublishAttempts = 5
+	draftRetryBaseDelay     = time.Minute
+	draftRetryMaxDelay      = time.Hour
+)
+
+var (
+	errParentChirpNotFound = errors.New("parent chirp not found")
+	errBlockedByAuthor     = errors.New("blocked by the parent chirp's author")
+	errEmptyDraft          = errors.New("draft is empty")
+)
+
+type draftParams struct {
+	Body      string     `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// Draft is a private, unpublished chirp. Drafts with a publish time are
+// published by the scheduler once it passes.
+type Draft struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+}
+
+type DraftPage struct {
+	Drafts     []Draft `json:"drafts"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
+}
+
+// Map database draft to Draft struct
+func draftFromDB(dbDraft database.Draft) Draft {
+	draft := Draft{
+		ID:        dbDraft.ID,
+		CreatedAt: dbDraft.CreatedAt,
+		UpdatedAt: dbDraft.UpdatedAt,
+		Body:      dbDraft.Body,
+	}
+
+	if dbDraft.ParentID.Valid {
+		draft.InReplyTo = &dbDraft.ParentID.UUID
+	}
+
+	if dbDraft.PublishAt.Valid {
+		draft.PublishAt = &dbDraft.PublishAt.Time
+	}
+
+	return draft
+}
+
+func draftCursor(draft Draft) pageCursor {
+	return pageCursor{CreatedAt: draft.CreatedAt, ID: draft.ID}
+}
+
+// validate returns a message describing what is wrong with the draft, or an
+// empty string if it is valid.
+func (params draftParams) validate(now time.Time) string {
+	if len(params.Body) > 140 {
+		return "Chirp is too long"
+	}
+
+	if params.PublishAt != nil {
+		if len(strings.TrimSpace(params.Body)) == 0 {
+			return "Scheduled chirp can't be empty"
+		}
+		if !params.PublishAt.After(now) {
+			return "Publish time must be in the future"
+		}
+		if params.PublishAt.Sub(now) > maxScheduleAhead {
+			return "Publish time must be within a year"
+		}
+	}
+
+	return ""
+}
+
+func (params draftParams) publishAt() sql.NullTime {
+	if params.PublishAt == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}
+}
+
+func (params draftParams) parentID() uuid.NullUUID {
+	if params.InReplyTo == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *params.InReplyTo, Valid: true}
+}
+
+// draftRetryDelay is how long to wait before publishing a draft again after
+// its attempts-th failed attempt.
+func draftRetryDelay(attempts int32) time.Duration {
+	delay := draftRetryBaseDelay
+	for i := int32(1); i < attempts && delay < draftRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, draftRetryMaxDelay)
+}
+
+// publishDraft turns a locked draft into a chirp and deletes the draft, both
+// within qtx's transaction. Empty drafts fail with errEmptyDraft, and replies
+// fail with errParentChirpNotFound or errBlockedByAuthor if the parent can no
+// longer be replied to.
+func publishDraft(ctx context.Context, qtx *database.Queries, draft database.Draft) (database.Chirp, []database.Notification, error) {
+	if len(strings.TrimSpace(draft.Body)) == 0 {
+		return database.Chirp{}, nil, errEmptyDraft
+	}
+
+	dbParams := database.CreateChirpParams{
+		Body:   replaceBadWords(draft.Body),
+		UserID: draft.UserID,
+		Kind:   "chirp",
+	}
+
+	// Attach reply to its parent and the parent's thread
+	var parentAuthorID uuid.UUID
+	if draft.ParentID.Valid {
+		parent, err := qtx.GetChirpById(ctx, draft.ParentID.UUID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && parent.DeletedAt.Valid) {
+			return database.Chirp{}, nil, errParentChirpNotFound
+		}
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+
+		blockParams := database.IsBlockedParams{
+			BlockerID: parent.UserID,
+			BlockedID: draft.UserID,
+		}
+
+		blocked, err := qtx.IsBlocked(ctx, blockParams)
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+		if blocked {
+			return database.Chirp{}, nil, errBlockedByAuthor
+		}
+
+		dbParams.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		dbParams.ThreadID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		if parent.ThreadID.Valid {
+			dbParams.ThreadID = parent.ThreadID
+		}
+		parentAuthorID = parent.UserID
+	}
+
+	dbChirp, notifications, err := insertChirp(ctx, qtx, dbParams, parentAuthorID, uuid.Nil)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	err = qtx.DeleteDraft(ctx, draft.ID)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	return dbChirp, notifications, nil
+}
+
+// publishScheduledChirps periodically publishes drafts whose publish time has
+// passed. Drafts are locked with SKIP LOCKED and deleted in the same
+// transaction as the chirp is created, so each one is published exactly once
+// even with several server instances running.
+func (cfg *apiConfig) publishScheduledChirps(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			published, err := cfg.publishDueDraft(ctx)
+			if err != nil {
+				log.Printf("Error publishing scheduled chirp: %v", err)
+				break
+			}
+			if !published {
+				break
+			}
+		}
+	}
+}
+
+// publishDueDraft publishes the earliest due draft that no other instance is
+// publishing, reporting whether there was one.
+func (cfg *apiConfig) publishDueDraft(ctx context.Context) (bool, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	draft, err := qtx.LockDueDraft(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	dbChirp, notifications, err := publishDraft(ctx, qtx, draft)
+	if errors.Is(err, errEmptyDraft) || errors.Is(err, errParentChirpNotFound) || errors.Is(err, errBlockedByAuthor) {
+		// Keep it as a plain draft instead of retrying forever
+		log.Printf("Unscheduling draft %s: %v", draft.ID, err)
+		err = qtx.UnscheduleDraft(ctx, draft.ID)
+		if err != nil {
+			return false, err
+		}
+		return true, tx.Commit()
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		// Move on to the other due drafts instead of retrying this one first
+		log.Printf("Error publishing scheduled draft %s: %v", draft.ID, err)
+		tx.Rollback()
+		return true, cfg.recordDraftPublishFailure(ctx, draft)
+	}
+
+	// Push notifications and the new chirp to connected clients
+	cfg.notificationHub.Publish(ctx, notifications)
+
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+	err = cfg.hydrateChirps(ctx, chirps, uuid.Nil)
+	if err != nil {
+		// The chirp is published, it just won't show up on live streams
+		log.Printf("Error getting scheduled chirp %s: %v", dbChirp.ID, err)
+		return true, nil
+	}
+	cfg.broker.Publish(ctx, "created", chirps[0])
+
+	return true, nil
+}
+
+// recordDraftPublishFailure pushes a draft that failed to publish back by
+// draftRetryDelay, or unschedules it once it used up its attempts. Another
+// instance recording the same failure first makes this a no-op.
+func (cfg *apiConfig) recordDraftPublishFailure(ctx context.Context, draft database.Draft) error {
+	attempts := draft.FailedAttempts + 1
+	if attempts >= maxDraftPublishAttempts {
+		log.Printf("Unscheduling draft %s after %d failed attempts", draft.ID, attempts)
+		return cfg.db.UnscheduleDraft(ctx, draft.ID)
+	}
+
+	dbParams := database.RecordDraftPublishFailureParams{
+		NextAttemptAt:  time.Now().UTC().Add(draftRetryDelay(attempts)),
+		ID:             draft.ID,
+		FailedAttempts: draft.FailedAttempts,
+	}
+
+	_, err := cfg.db.RecordDraftPublishFailure(ctx, dbParams)
+	return err
+}
+
+func (cfg *apiConfig) createDraftHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Decode request body
+	decoder := json.NewDecoder(rq.Body)
+	params := draftParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if body or publish time are not valid
+	if msg := params.validate(time.Now().UTC()); len(msg) > 0 {
+		err = respondWithError(rw, http.StatusBadRequest, msg)
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure the parent chirp exists
+	if params.InReplyTo != nil {
+		parent, err := cfg.db.GetChirpById(rq.Context(), *params.InReplyTo)
+		if err != nil || parent.DeletedAt.Valid {
+			err = respondWithError(rw, http.StatusNotFound, "Parent chirp not found")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	// Insert draft into database
+	dbParams := database.CreateDraftParams{
+		UserID:    userID,
+		Body:      params.Body,
+		ParentID:  params.parentID(),
+		PublishAt: params.publishAt(),
+	}
+
+	dbDraft, err := cfg.db.CreateDraft(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return draft
+	err = respondWithJSON(rw, http.StatusCreated, draftFromDB(dbDraft))
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getDraftsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters (newest first by default)
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if len(rq.URL.Query().Get("sort")) == 0 {
+		page.Sort = "desc"
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Get drafts from database
+	dbParams := database.GetDraftsByUserParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbDrafts, err := cfg.db.GetDraftsByUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting drafts")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database drafts to Draft struct
+	drafts := make([]Draft, len(dbDrafts))
+	for i, dbDraft := range dbDrafts {
+		drafts[i] = draftFromDB(dbDraft)
+	}
+
+	// Trim to the requested page and build cursors
+	drafts, next, prev := paginate(drafts, page, draftCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Return drafts
+	err = respondWithJSON(rw, http.StatusOK, DraftPage{Drafts: drafts, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getDraftHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get draft ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid draft ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get draft from database
+	dbDraft, err := cfg.db.GetDraftById(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "Draft not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not draft owner
+	if dbDraft.UserID != userID {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return draft
+	err = respondWithJSON(rw, http.StatusOK, draftFromDB(dbDraft))
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) updateDraftHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get draft ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid draft ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Decode request body
+	decoder := json.NewDecoder(rq.Body)
+	params := draftParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if body or publish time are not valid
+	if msg := params.validate(time.Now().UTC()); len(msg) > 0 {
+		err = respondWithError(rw, http.StatusBadRequest, msg)
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure the parent chirp exists
+	if params.InReplyTo != nil {
+		parent, err := cfg.db.GetChirpById(rq.Context(), *params.InReplyTo)
+		if err != nil || parent.DeletedAt.Valid {
+			err = respondWithError(rw, http.StatusNotFound, "Parent chirp not found")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	// Start transaction so the draft can't be published while it is edited
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Get and lock draft from database (it is gone if it was just published)
+	dbDraft, err := qtx.LockDraft(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "Draft not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not draft owner
+	if dbDraft.UserID != userID {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Update draft in database
+	dbParams := database.UpdateDraftParams{
+		ID:        id,
+		Body:      params.Body,
+		ParentID:  params.parentID(),
+		PublishAt: params.publishAt(),
+	}
+
+	dbDraft, err = qtx.UpdateDraft(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return draft
+	err = respondWithJSON(rw, http.StatusOK, draftFromDB(dbDraft))
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) deleteDraftHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get draft ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid draft ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the draft can't be published while it is deleted
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Get and lock draft from database
+	dbDraft, err := qtx.LockDraft(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "Draft not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not draft owner
+	if dbDraft.UserID != userID {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Delete draft from database
+	err = qtx.DeleteDraft(rq.Context(), id)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) publishDraftHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get draft ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid draft ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the draft is published only once
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error publishing draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Get and lock draft from database (it is gone if the scheduler published it)
+	dbDraft, err := qtx.LockDraft(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "Draft not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not draft owner
+	if dbDraft.UserID != userID {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Insert chirp into database and delete the draft
+	dbChirp, notifications, err := publishDraft(rq.Context(), qtx, dbDraft)
+	if errors.Is(err, errParentChirpNotFound) {
+		err = respondWithError(rw, http.StatusNotFound, "Parent chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if errors.Is(err, errEmptyDraft) {
+		err = respondWithError(rw, http.StatusBadRequest, "Draft is empty")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if errors.Is(err, errBlockedByAuthor) {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error publishing draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Push notifications to connected clients
+	cfg.notificationHub.Publish(rq.Context(), notifications)
+
+	// Map database chirp to Chirp struct
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error publishing draft")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Push chirp to stream subscribers
+	cfg.broker.Publish(rq.Context(), "created", chirps[0])
+
+	// Return chirp
+	err = respondWithJSON(rw, http.StatusCreated, chirps[0])
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDraftParamsValidate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		publishAt := now.Add(d)
+		return &publishAt
+	}
+
+	tests := []struct {
+		name     string
+		params   draftParams
+		expected string
+	}{
+		{"AcceptsDraft", draftParams{Body: "Hello"}, ""},
+		{"AcceptsEmptyDraft", draftParams{}, ""},
+		{"AcceptsScheduledChirp", draftParams{Body: "Hello", PublishAt: at(time.Hour)}, ""},
+		{"RejectsLongBody", draftParams{Body: strings.Repeat("a", 141)}, "Chirp is too long"},
+		{"RejectsPastPublishTime", draftParams{Body: "Hello", PublishAt: at(-time.Minute)}, "Publish time must be in the future"},
+		{"RejectsCurrentPublishTime", draftParams{Body: "Hello", PublishAt: at(0)}, "Publish time must be in the future"},
+		{"RejectsFarPublishTime", draftParams{Body: "Hello", PublishAt: at(400 * 24 * time.Hour)}, "Publish time must be within a year"},
+		{"RejectsEmptyScheduledChirp", draftParams{PublishAt: at(time.Hour)}, "Scheduled chirp can't be empty"},
+		{"RejectsBlankScheduledChirp", draftParams{Body: "  \n ", PublishAt: at(time.Hour)}, "Scheduled chirp can't be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.params.validate(now)
+			if result != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDraftRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int32
+		expected time.Duration
+	}{
+		{"FirstFailure", 1, time.Minute},
+		{"SecondFailure", 2, 2 * time.Minute},
+		{"FourthFailure", 4, 8 * time.Minute},
+		{"CapsDelay", 10, time.Hour},
+		{"HugeAttemptCount", 1000, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := draftRetryDelay(tt.attempts)
+			if result != tt.expected {
+				t.Errorf("Expected %v but got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"time"
+)
+
+type Follow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowPage struct {
+	Users      []Follow `json:"users"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	PrevCursor string   `json:"prev_cursor,omitempty"`
+}
+
+func followCursor(follow Follow) pageCursor {
+	return pageCursor{CreatedAt: follow.FollowedAt, ID: follow.UserID}
+}
+
+func (cfg *apiConfig) followUserHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	followeeID, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user tries to follow themselves
+	if followeeID == userID {
+		err = respondWithError(rw, http.StatusBadRequest, "Cannot follow yourself")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure followed user exists
+	_, err = cfg.db.GetUserByID(rq.Context(), followeeID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "User not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if the followed user blocked the user
+	blockParams := database.IsBlockedParams{
+		BlockerID: followeeID,
+		BlockedID: userID,
+	}
+
+	blocked, err := cfg.db.IsBlocked(rq.Context(), blockParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error following user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if blocked {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the follow and its notification are saved together
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error following user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Insert follow into database (following twice is a no-op)
+	dbParams := database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	}
+
+	rows, err := qtx.FollowUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error following user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Notify the followed user the first time they are followed
+	var notifications []database.Notification
+	if rows > 0 {
+		notifications, err = createNotification(rq.Context(), qtx, followeeID, userID, "follow", uuid.NullUUID{})
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error following user")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error following user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Push notifications to connected clients
+	cfg.notificationHub.Publish(rq.Context(), notifications)
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) unfollowUserHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	followeeID, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Delete follow from database
+	dbParams := database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	}
+
+	_, err = cfg.db.UnfollowUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error unfollowing user")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) getFollowersHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Get followers from database
+	dbParams := database.GetFollowersParams{
+		FolloweeID:      id,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbFollowers, err := cfg.db.GetFollowers(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting followers")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database rows to Follow struct
+	follows := make([]Follow, len(dbFollowers))
+	for i, dbFollower := range dbFollowers {
+		follows[i] = Follow{
+			UserID:     dbFollower.UserID,
+			FollowedAt: dbFollower.CreatedAt,
+		}
+	}
+
+	// Trim to the requested page and build cursors
+	follows, next, prev := paginate(follows, page, followCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Return followers
+	err = respondWithJSON(rw, http.StatusOK, FollowPage{Users: follows, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getFollowingHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Get followed users from database
+	dbParams := database.GetFollowingParams{
+		FollowerID:      id,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbFollowing, err := cfg.db.GetFollowing(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting followed users")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database rows to Follow struct
+	follows := make([]Follow, len(dbFollowing))
+	for i, dbFollow := range dbFollowing {
+		follows[i] = Follow{
+			UserID:     dbFollow.UserID,
+			FollowedAt: dbFollow.CreatedAt,
+		}
+	}
+
+	// Trim to the requested page and build cursors
+	follows, next, prev := paginate(follows, page, followCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Return followed users
+	err = respondWithJSON(rw, http.StatusOK, FollowPage{Users: follows, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
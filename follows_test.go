@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestFollowCursor(t *testing.T) {
+	followedAt := time.Date(2024, 10, 1, 12, 30, 0, 0, time.UTC)
+	follow := Follow{UserID: uuid.New(), FollowedAt: followedAt}
+
+	got := followCursor(follow)
+	if !got.CreatedAt.Equal(followedAt) || got.ID != follow.UserID || got.Prev {
+		t.Errorf("followCursor() got = %v, want %v/%v", got, followedAt, follow.UserID)
+	}
+
+	decoded, err := decodeCursor(encodeCursor(got))
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(followedAt) || decoded.ID != follow.UserID {
+		t.Errorf("decodeCursor() got = %v, want %v", decoded, got)
+	}
+}
+
+func TestPaginateFollows(t *testing.T) {
+	base := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	follows := make([]Follow, 4)
+	for i := range follows {
+		follows[i] = Follow{UserID: uuid.New(), FollowedAt: base.Add(time.Duration(i) * time.Hour)}
+	}
+	// Users followed in the same instant are ordered by user ID
+	tied := []Follow{
+		{UserID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), FollowedAt: base},
+		{UserID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), FollowedAt: base},
+		{UserID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), FollowedAt: base},
+	}
+	cursor := &pageCursor{CreatedAt: base, ID: uuid.New()}
+	prevCursor := &pageCursor{CreatedAt: base, ID: uuid.New(), Prev: true}
+
+	tests := []struct {
+		name      string
+		rows      []Follow
+		params    pageParams
+		wantFirst uuid.UUID
+		wantLast  uuid.UUID
+		wantLen   int
+		wantNext  bool
+		wantPrev  bool
+	}{
+		{"FirstPageWithMore", follows[:3], pageParams{Limit: 2}, follows[0].UserID, follows[1].UserID, 2, true, false},
+		{"FirstPageOnly", follows[:2], pageParams{Limit: 2}, follows[0].UserID, follows[1].UserID, 2, false, false},
+		{"NextPage", follows[2:], pageParams{Limit: 2, Cursor: cursor}, follows[2].UserID, follows[3].UserID, 2, false, true},
+		{"PrevPage", []Follow{follows[1], follows[0]}, pageParams{Limit: 2, Cursor: prevCursor}, follows[0].UserID, follows[1].UserID, 2, true, false},
+		{"TiedFollowedAt", tied, pageParams{Limit: 2}, tied[0].UserID, tied[1].UserID, 2, true, false},
+		{"NoFollows", nil, pageParams{Limit: 2}, uuid.Nil, uuid.Nil, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := append([]Follow(nil), tt.rows...)
+			page, next, prev := paginate(rows, tt.params, followCursor)
+			if len(page) != tt.wantLen {
+				t.Fatalf("paginate() len = %d, want %d", len(page), tt.wantLen)
+			}
+			if len(page) > 0 && (page[0].UserID != tt.wantFirst || page[len(page)-1].UserID != tt.wantLast) {
+				t.Errorf("paginate() got = %v..%v, want %v..%v", page[0].UserID, page[len(page)-1].UserID, tt.wantFirst, tt.wantLast)
+			}
+			if (len(next) > 0) != tt.wantNext {
+				t.Errorf("paginate() next = %q, wantNext %v", next, tt.wantNext)
+			}
+			if (len(prev) > 0) != tt.wantPrev {
+				t.Errorf("paginate() prev = %q, wantPrev %v", prev, tt.wantPrev)
+			}
+
+			// The next cursor must resume right after the last follow on the page
+			if len(next) > 0 {
+				got, err := decodeCursor(next)
+				if err != nil {
+					t.Fatalf("decodeCursor() error = %v", err)
+				}
+				last := page[len(page)-1]
+				if got.ID != last.UserID || !got.CreatedAt.Equal(last.FollowedAt) || got.Prev {
+					t.Errorf("paginate() next cursor = %v, want %v/%v", got, last.FollowedAt, last.UserID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A hashtag starts after whitespace or punctuation and must contain at least
+// one letter, so "#1" or "abc#def" don't count.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_#&])#([\p{L}\p{M}\p{N}_]*[\p{L}\p{M}][\p{L}\p{M}\p{N}_]*)`)
+
+var trendingWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+type TrendingHashtag struct {
+	Tag        string `json:"tag"`
+	ChirpCount int64  `json:"chirp_count"`
+}
+
+// extractHashtags returns the distinct lowercased hashtags in body in the
+// order they first appear.
+func extractHashtags(body string) []string {
+	matches := hashtagPattern.FindAllStringSubmatch(body, -1)
+
+	tags := make([]string, 0, len(matches))
+	seen := make(map[string]bool, len(matches))
+	for _, match := range matches {
+		tag := strings.ToLower(match[1])
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+func (cfg *apiConfig) getHashtagChirpsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get hashtag from URL (with or without the leading #)
+	tag := strings.ToLower(strings.TrimPrefix(rq.PathValue("tag"), "#"))
+	if len(tag) == 0 {
+		err := respondWithError(rw, http.StatusBadRequest, "Invalid hashtag")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Get chirps with hashtag from database
+	dbParams := database.GetChirpsByHashtagParams{
+		Tag:             tag,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbRows, err := cfg.db.GetChirpsByHashtag(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirps")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database chirps to Chirp struct
+	chirps := make([]Chirp, len(dbRows))
+	for i, dbRow := range dbRows {
+		chirps[i] = chirpFromDB(dbRow.Chirp)
+	}
+
+	// Trim to the requested page and build cursors
+	chirps, next, prev := paginate(chirps, page, chirpCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, cfg.getOptionalUserID(rq))
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirps")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return chirps
+	err = respondWithJSON(rw, http.StatusOK, ChirpPage{Chirps: chirps, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getTrendingHashtagsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Check for window parameter
+	windowParam := rq.URL.Query().Get("window")
+	if len(windowParam) == 0 {
+		windowParam = "24h"
+	}
+
+	window, ok := trendingWindows[windowParam]
+	if !ok {
+		err := respondWithError(rw, http.StatusBadRequest, "Invalid window parameter")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for limit parameter
+	limit := 10
+	if limitParam := rq.URL.Query().Get("limit"); len(limitParam) > 0 {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > 50 {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid limit parameter")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	// Get trending hashtags from database
+	dbParams := database.GetTrendingHashtagsParams{
+		Since:    time.Now().UTC().Add(-window),
+		TagLimit: int32(limit),
+	}
+
+	dbTags, err := cfg.db.GetTrendingHashtags(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting trending hashtags")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database rows to TrendingHashtag struct
+	tags := make([]TrendingHashtag, len(dbTags))
+	for i, dbTag := range dbTags {
+		tags[i] = TrendingHashtag{
+			Tag:        dbTag.Tag,
+			ChirpCount: dbTag.ChirpCount,
+		}
+	}
+
+	// Return hashtags
+	err = respondWithJSON(rw, http.StatusOK, tags)
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
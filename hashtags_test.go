@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"FindsHashtags", "Learning #golang with #bootdev", []string{"golang", "bootdev"}},
+		{"LowercasesAndDedupes", "#Go #go #GO", []string{"go"}},
+		{"HandlesUnicode", "Café au lait #café #東京 #Ünïcödé", []string{"café", "東京", "ünïcödé"}},
+		{"StopsAtPunctuation", "So good (#chirpy), really #fun!", []string{"chirpy", "fun"}},
+		{"AllowsDigitsAndUnderscores", "#go_1_23 #2024goals", []string{"go_1_23", "2024goals"}},
+		{"IgnoresNumbersOnly", "We're #1 and #42", []string{}},
+		{"IgnoresMidWord", "email me at abc#def or &#39;", []string{}},
+		{"IgnoresBareHash", "# alone and ##", []string{}},
+		{"HandlesEmptyString", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractHashtags(tt.input)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v but got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -2,6 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"net/http"
 	"strings"
 )
@@ -37,6 +41,28 @@ func respondWithNoContent(rw http.ResponseWriter) {
 	rw.WriteHeader(http.StatusNoContent)
 }
 
+// getOptionalUserID returns the ID of the caller on endpoints that also allow
+// anonymous access. A missing or invalid token is treated as anonymous.
+func (cfg *apiConfig) getOptionalUserID(rq *http.Request) uuid.UUID {
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return userID
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 func replaceBadWords(body string) string {
 	// Bad words map
 	badWords := map[string]string{
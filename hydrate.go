@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// hydrateChirps fills in the per-chirp values that aren't stored on the chirps
+// table. Everything is loaded for the whole slice at once to avoid a query per
+// chirp on the listing endpoints. Viewer-specific fields are only set when
+// viewerID is not uuid.Nil.
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, chirps []Chirp, viewerID uuid.UUID) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	// Get chirps embedded by rechirps and quotes
+	originalIDs := make([]uuid.UUID, 0)
+	for _, chirp := range chirps {
+		if chirp.OriginalID != nil {
+			originalIDs = append(originalIDs, *chirp.OriginalID)
+		}
+	}
+
+	originals := make([]Chirp, 0, len(originalIDs))
+	if len(originalIDs) > 0 {
+		dbOriginals, err := cfg.db.GetChirpsByIds(ctx, originalIDs)
+		if err != nil {
+			return err
+		}
+		for _, dbOriginal := range dbOriginals {
+			originals = append(originals, chirpFromDB(dbOriginal))
+		}
+	}
+
+	// Get counts for the chirps and their originals
+	err := cfg.hydrateChirpCounts(ctx, chirps, viewerID)
+	if err != nil {
+		return err
+	}
+
+	if len(originals) > 0 {
+		err = cfg.hydrateChirpCounts(ctx, originals, viewerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Get attached media and polls for the chirps and their originals
+	err = cfg.hydrateChirpMedia(ctx, chirps)
+	if err == nil {
+		err = cfg.hydrateChirpPolls(ctx, chirps, viewerID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(originals) > 0 {
+		err = cfg.hydrateChirpMedia(ctx, originals)
+		if err == nil {
+			err = cfg.hydrateChirpPolls(ctx, originals, viewerID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	originalsByID := make(map[uuid.UUID]Chirp, len(originals))
+	for _, original := range originals {
+		originalsByID[original.ID] = original
+	}
+
+	for i := range chirps {
+		if chirps[i].Kind == "chirp" {
+			continue
+		}
+
+		// Originals removed along with their author show up as a bare tombstone
+		original := Chirp{Kind: "chirp", Deleted: true}
+		if chirps[i].OriginalID != nil {
+			if found, ok := originalsByID[*chirps[i].OriginalID]; ok {
+				original = found
+			}
+		}
+		chirps[i].Original = &original
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) hydrateChirpCounts(ctx context.Context, chirps []Chirp, viewerID uuid.UUID) error {
+	ids := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		ids[i] = chirp.ID
+	}
+
+	// Get reply counts
+	dbReplyCounts, err := cfg.db.GetReplyCounts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	replyCounts := make(map[uuid.UUID]int64, len(dbReplyCounts))
+	for _, row := range dbReplyCounts {
+		replyCounts[row.ParentID.UUID] = row.ReplyCount
+	}
+
+	// Get like counts
+	dbLikeCounts, err := cfg.db.GetLikeCounts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	likeCounts := make(map[uuid.UUID]int64, len(dbLikeCounts))
+	for _, row := range dbLikeCounts {
+		likeCounts[row.ChirpID] = row.LikeCount
+	}
+
+	for i := range chirps {
+		chirps[i].ReplyCount = replyCounts[chirps[i].ID]
+		chirps[i].LikeCount = likeCounts[chirps[i].ID]
+	}
+
+	if viewerID == uuid.Nil {
+		return nil
+	}
+
+	// Get chirps liked by the viewer
+	dbParams := database.GetLikedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: ids,
+	}
+
+	likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, dbParams)
+	if err != nil {
+		return err
+	}
+
+	liked := make(map[uuid.UUID]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+
+	for i := range chirps {
+		likedByMe := liked[chirps[i].ID]
+		chirps[i].LikedByMe = &likedByMe
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
@@ -20,58 +21,110 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	// Validate token secret
-	if len(tokenSecret) == 0 {
-		return "", errors.New("token secret cannot be empty")
-	}
+// AccessToken holds the claims of a validated access token. ID is empty for
+// legacy tokens issued before tokens had one, and SessionID is uuid.Nil for
+// tokens not tied to a session.
+type AccessToken struct {
+	UserID    uuid.UUID
+	ID        string
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
+
+// accessTokenClaims adds the session (refresh token family) the token was
+// issued for to the registered claims.
+type accessTokenClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+}
 
-	// Signing key
-	signingKey := []byte(tokenSecret)
+func MakeJWT(userID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	return MakeSessionJWT(userID, uuid.Nil, keyring, expiresIn)
+}
 
-	// Create claims
-	claims := jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
+// MakeSessionJWT also sets the sid claim, so the token stops working once its
+// session is revoked. A nil sessionID leaves the claim out.
+func MakeSessionJWT(userID, sessionID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	// Validate keyring
+	if keyring == nil {
+		return "", errors.New("keyring cannot be nil")
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(signingKey)
-	if err != nil {
-		return "", err
+	// Create claims (the ID lets a single token be revoked before it expires)
+	claims := accessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    keyring.issuer(),
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject:   userID.String(),
+			ID:        uuid.NewString(),
+		},
+	}
+	if len(keyring.options.Audience) > 0 {
+		claims.Audience = jwt.ClaimStrings{keyring.options.Audience}
+	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
 	}
 
-	return signedToken, nil
+	// Create token signed with the active key
+	return keyring.Sign(claims)
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	// Signing key
-	signingKey := []byte(tokenSecret)
+func ValidateJWT(tokenString string, keyring *Keyring) (uuid.UUID, error) {
+	accessToken, err := ParseAccessToken(tokenString, keyring)
+	return accessToken.UserID, err
+}
 
-	// Validate token
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return signingKey, nil
-	})
-	if err != nil {
-		return uuid.Nil, err
+// ValidateJWTWithExpiry also returns when the token expires, for callers that
+// keep using it after the request is validated.
+func ValidateJWTWithExpiry(tokenString string, keyring *Keyring) (uuid.UUID, time.Time, error) {
+	accessToken, err := ParseAccessToken(tokenString, keyring)
+	return accessToken.UserID, accessToken.ExpiresAt, err
+}
+
+// ParseAccessToken validates the token against the keyring and its validation
+// options. Tokens must be signed with an allowed algorithm and carry the
+// expected issuer and audience, an expiry and an ID. Legacy HS256 tokens
+// without a kid predate token IDs and are let through without one until the
+// keyring's legacy cutoff. It doesn't
+// consult a denylist; callers that revoke tokens check the returned ID and
+// session themselves.
+func ParseAccessToken(tokenString string, keyring *Keyring) (AccessToken, error) {
+	// Validate keyring
+	if keyring == nil {
+		return AccessToken{}, errors.New("keyring cannot be nil")
 	}
 
-	// Get subject claim from token
-	subject, err := token.Claims.GetSubject()
+	// Validate token
+	token, err := jwt.ParseWithClaims(tokenString, &accessTokenClaims{}, keyring.verificationKey, keyring.parserOptions()...)
 	if err != nil {
-		return uuid.Nil, err
+		return AccessToken{}, err
 	}
+	claims := token.Claims.(*accessTokenClaims)
 
 	// Get user ID from subject claim
-	userID, err := uuid.Parse(subject)
+	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return AccessToken{}, err
+	}
+
+	// Make sure the token can be revoked
+	_, hasKeyID := token.Header["kid"]
+	if len(claims.ID) == 0 && hasKeyID {
+		return AccessToken{}, errors.New("token has no ID")
+	}
+
+	// Get session ID from sid claim
+	sessionID := uuid.Nil
+	if len(claims.SessionID) > 0 {
+		sessionID, err = uuid.Parse(claims.SessionID)
+		if err != nil {
+			return AccessToken{}, err
+		}
 	}
 
-	return userID, nil
+	return AccessToken{UserID: userID, ID: claims.ID, SessionID: sessionID, ExpiresAt: claims.ExpiresAt.Time}, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -124,3 +177,11 @@ func MakeRefreshToken() (string, error) {
 
 	return hex.EncodeToString(token), nil
 }
+
+// HashRefreshToken returns the SHA-256 hex digest stored in place of a refresh
+// token. Refresh tokens are 256 random bits, so an unsalted fast hash is
+// enough to keep a database dump from handing out live sessions.
+func HashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
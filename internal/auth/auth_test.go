@@ -54,22 +54,33 @@ func TestCheckPasswordHash(t *testing.T) {
 	}
 }
 
+func newTestKeyring(t *testing.T, secret string) *Keyring {
+	t.Helper()
+	keyring, err := NewHMACKeyring("test", secret)
+	if err != nil {
+		t.Fatalf("NewHMACKeyring() error = %v", err)
+	}
+	return keyring
+}
+
 func TestMakeJWT(t *testing.T) {
+	validKeyring := newTestKeyring(t, "mysecret")
+
 	tests := []struct {
-		name        string
-		userID      uuid.UUID
-		tokenSecret string
-		expiresIn   time.Duration
-		wantErr     bool
+		name      string
+		userID    uuid.UUID
+		keyring   *Keyring
+		expiresIn time.Duration
+		wantErr   bool
 	}{
-		{"ValidToken", uuid.New(), "mysecret", time.Hour, false},
-		{"EmptySecret", uuid.New(), "", time.Hour, true},
-		{"ZeroExpiration", uuid.New(), "mysecret", 0, false},
+		{"ValidToken", uuid.New(), validKeyring, time.Hour, false},
+		{"NilKeyring", uuid.New(), nil, time.Hour, true},
+		{"ZeroExpiration", uuid.New(), validKeyring, 0, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := MakeJWT(tt.userID, tt.tokenSecret, tt.expiresIn)
+			token, err := MakeJWT(tt.userID, tt.keyring, tt.expiresIn)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("MakeJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -83,24 +94,25 @@ func TestMakeJWT(t *testing.T) {
 
 func TestValidateJWT(t *testing.T) {
 	validUserID := uuid.New()
-	validTokenSecret := "mysecret"
-	validToken, _ := MakeJWT(validUserID, validTokenSecret, time.Hour)
+	validKeyring := newTestKeyring(t, "mysecret")
+	validToken, _ := MakeJWT(validUserID, validKeyring, time.Hour)
 
 	tests := []struct {
 		name        string
 		tokenString string
-		tokenSecret string
+		keyring     *Keyring
 		wantErr     bool
 		wantUserID  uuid.UUID
 	}{
-		{"ValidToken", validToken, validTokenSecret, false, validUserID},
-		{"InvalidToken", "invalidtoken", validTokenSecret, true, uuid.Nil},
-		{"WrongSecret", validToken, "wrongsecret", true, uuid.Nil},
+		{"ValidToken", validToken, validKeyring, false, validUserID},
+		{"InvalidToken", "invalidtoken", validKeyring, true, uuid.Nil},
+		{"WrongSecret", validToken, newTestKeyring(t, "wrongsecret"), true, uuid.Nil},
+		{"NilKeyring", validToken, nil, true, uuid.Nil},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.tokenString, tt.tokenSecret)
+			gotUserID, err := ValidateJWT(tt.tokenString, tt.keyring)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -112,6 +124,42 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+func TestValidateJWTWithExpiry(t *testing.T) {
+	validUserID := uuid.New()
+	validKeyring := newTestKeyring(t, "mysecret")
+	validToken, _ := MakeJWT(validUserID, validKeyring, time.Hour)
+	expiredToken, _ := MakeJWT(validUserID, validKeyring, -time.Hour)
+
+	tests := []struct {
+		name        string
+		tokenString string
+		keyring     *Keyring
+		wantErr     bool
+		wantUserID  uuid.UUID
+		wantExpiry  time.Duration
+	}{
+		{"ValidToken", validToken, validKeyring, false, validUserID, time.Hour},
+		{"ExpiredToken", expiredToken, validKeyring, true, uuid.Nil, 0},
+		{"WrongSecret", validToken, newTestKeyring(t, "wrongsecret"), true, uuid.Nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID, gotExpiry, err := ValidateJWTWithExpiry(tt.tokenString, tt.keyring)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWTWithExpiry() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotUserID != tt.wantUserID {
+				t.Errorf("ValidateJWTWithExpiry() gotUserID = %v, want %v", gotUserID, tt.wantUserID)
+			}
+			if !tt.wantErr && time.Until(gotExpiry).Round(time.Minute) != tt.wantExpiry {
+				t.Errorf("ValidateJWTWithExpiry() gotExpiry = %v, want in %v", gotExpiry, tt.wantExpiry)
+			}
+		})
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -205,3 +253,31 @@ func TestGetAPIKey(t *testing.T) {
 		})
 	}
 }
+
+func TestHashRefreshToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{
+			name:  "EmptyToken",
+			token: "",
+			want:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:  "KnownToken",
+			token: "abc",
+			want:  "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HashRefreshToken(tt.token)
+			if got != tt.want {
+				t.Errorf("HashRefreshToken() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
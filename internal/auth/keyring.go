@@ -0,0 +1,473 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is a JWT signing or verification key identified by its kid. HS256 keys
+// use Secret. Asymmetric keys need PrivateKey to sign; retired keys that only
+// verify can get by with PublicKey. A zero ExpiresAt never expires.
+type Key struct {
+	ID         string
+	Algorithm  string
+	Secret     []byte
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	ExpiresAt  time.Time
+}
+
+// Keyring holds every key tokens may be signed with. New tokens are signed
+// with the active key, while retired keys keep verifying the tokens they
+// signed until the key itself expires.
+type Keyring struct {
+	keys    map[string]Key
+	active  string
+	options ValidationOptions
+}
+
+// ValidationOptions tighten which tokens the keyring accepts. Issuer and
+// Audience are also set on the tokens MakeJWT issues. An empty Issuer means
+// "chirpy", an empty Audience isn't checked and empty Algorithms allow every
+// algorithm in the keyring. Legacy HS256 tokens without a kid are only
+// accepted if they were issued before LegacyIssuedBefore; the zero time
+// rejects them all.
+type ValidationOptions struct {
+	Issuer             string
+	Audience           string
+	Algorithms         []string
+	Leeway             time.Duration
+	LegacyIssuedBefore time.Time
+}
+
+// JWK is the public part of a key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewKeyring checks the keys and returns a keyring signing with activeID.
+func NewKeyring(keys []Key, activeID string) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string]Key, len(keys)), active: activeID}
+
+	for _, key := range keys {
+		if len(key.ID) == 0 {
+			return nil, errors.New("key ID cannot be empty")
+		}
+		if _, ok := keyring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+
+		// Fill in the public key from the private key where possible, or make
+		// sure the configured one belongs to it
+		if key.PrivateKey != nil {
+			if key.PublicKey == nil {
+				key.PublicKey = key.PrivateKey.Public()
+			} else if !publicKeyMatches(key.PrivateKey, key.PublicKey) {
+				return nil, fmt.Errorf("key %q: public key does not match private key", key.ID)
+			}
+		}
+
+		err := key.check()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.ID, err)
+		}
+		keyring.keys[key.ID] = key
+	}
+
+	// Make sure the active key can sign
+	activeKey, ok := keyring.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", activeID)
+	}
+	if activeKey.Algorithm != AlgHS256 && activeKey.PrivateKey == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeID)
+	}
+	if activeKey.expired(time.Now()) {
+		return nil, fmt.Errorf("active key %q has expired", activeID)
+	}
+
+	return keyring, nil
+}
+
+// NewHMACKeyring returns a keyring with a single HS256 key.
+func NewHMACKeyring(id, secret string) (*Keyring, error) {
+	// Validate token secret
+	if len(secret) == 0 {
+		return nil, errors.New("token secret cannot be empty")
+	}
+
+	return NewKeyring([]Key{{ID: id, Algorithm: AlgHS256, Secret: []byte(secret)}}, id)
+}
+
+type keyringFile struct {
+	Active string `json:"active"`
+	Keys   []struct {
+		ID             string    `json:"kid"`
+		Algorithm      string    `json:"alg"`
+		Secret         string    `json:"secret"`
+		PrivateKeyFile string    `json:"private_key_file"`
+		PublicKeyFile  string    `json:"public_key_file"`
+		ExpiresAt      time.Time `json:"expires_at"`
+	} `json:"keys"`
+}
+
+// LoadKeyring reads a keyring from a JSON file listing the keys. PEM key file
+// paths are relative to the keyring file. A non-empty activeID overrides the
+// active key named in the file.
+func LoadKeyring(path, activeID string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := keyringFile{}
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if len(activeID) == 0 {
+		activeID = file.Active
+	}
+
+	dir := filepath.Dir(path)
+	keys := make([]Key, len(file.Keys))
+	for i, fileKey := range file.Keys {
+		keys[i] = Key{
+			ID:        fileKey.ID,
+			Algorithm: fileKey.Algorithm,
+			ExpiresAt: fileKey.ExpiresAt,
+		}
+		if len(fileKey.Secret) > 0 {
+			keys[i].Secret = []byte(fileKey.Secret)
+		}
+
+		if len(fileKey.PrivateKeyFile) > 0 {
+			keys[i].PrivateKey, err = readPrivateKey(resolvePath(dir, fileKey.PrivateKeyFile))
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", fileKey.ID, err)
+			}
+		}
+
+		if len(fileKey.PublicKeyFile) > 0 {
+			keys[i].PublicKey, err = readPublicKey(resolvePath(dir, fileKey.PublicKeyFile))
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", fileKey.ID, err)
+			}
+		}
+	}
+
+	return NewKeyring(keys, activeID)
+}
+
+// SetValidationOptions replaces the keyring's validation options. Call it
+// before the keyring is shared between goroutines.
+func (k *Keyring) SetValidationOptions(options ValidationOptions) error {
+	for _, alg := range options.Algorithms {
+		if !slices.Contains(k.Algorithms(), alg) {
+			return fmt.Errorf("no key uses allowed algorithm %q", alg)
+		}
+	}
+	if options.Leeway < 0 {
+		return errors.New("leeway cannot be negative")
+	}
+
+	k.options = options
+	return nil
+}
+
+func (k *Keyring) issuer() string {
+	if len(k.options.Issuer) == 0 {
+		return "chirpy"
+	}
+	return k.options.Issuer
+}
+
+// parserOptions turns the validation options into jwt parser options.
+func (k *Keyring) parserOptions() []jwt.ParserOption {
+	algorithms := k.options.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = k.Algorithms()
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithIssuer(k.issuer()),
+		jwt.WithLeeway(k.options.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if len(k.options.Audience) > 0 {
+		options = append(options, jwt.WithAudience(k.options.Audience))
+	}
+	return options
+}
+
+// ActiveKeyID returns the kid new tokens are signed with.
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// Sign signs the claims with the active key and sets the kid header.
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	key := k.keys[k.active]
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+
+	if key.Algorithm == AlgHS256 {
+		return token.SignedString(key.Secret)
+	}
+	return token.SignedString(key.PrivateKey)
+}
+
+// Algorithms returns the algorithms used by keys in the keyring.
+func (k *Keyring) Algorithms() []string {
+	seen := make(map[string]bool)
+	algorithms := make([]string, 0, len(k.keys))
+	for _, key := range k.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algorithms = append(algorithms, key.Algorithm)
+		}
+	}
+	return algorithms
+}
+
+// verificationKey is the jwt.Keyfunc for tokens signed by the keyring. The key
+// is picked by kid and must match the token's alg, so a token can't switch an
+// RSA public key into an HMAC secret. Tokens issued before kid headers were
+// added are checked against the unexpired HS256 keys, as long as they were
+// issued before the LegacyIssuedBefore cutoff.
+func (k *Keyring) verificationKey(token *jwt.Token) (interface{}, error) {
+	now := time.Now()
+	alg := token.Method.Alg()
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		if alg != AlgHS256 || !k.legacyToken(token) {
+			return nil, errors.New("token has no key ID")
+		}
+
+		keySet := jwt.VerificationKeySet{}
+		for _, key := range k.keys {
+			if key.Algorithm == AlgHS256 && !key.expired(now) {
+				keySet.Keys = append(keySet.Keys, key.Secret)
+			}
+		}
+		if len(keySet.Keys) == 0 {
+			return nil, errors.New("token has no key ID")
+		}
+		return keySet, nil
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if key.expired(now) {
+		return nil, fmt.Errorf("key %q has expired", kid)
+	}
+	if alg != key.Algorithm {
+		return nil, fmt.Errorf("key %q does not use %s", kid, alg)
+	}
+
+	if key.Algorithm == AlgHS256 {
+		return key.Secret, nil
+	}
+	return key.PublicKey, nil
+}
+
+// legacyToken reports whether a token without a kid was issued before the
+// legacy cutoff. Such tokens have no ID either, so they can't be revoked and
+// are only let through until they run out.
+func (k *Keyring) legacyToken(token *jwt.Token) bool {
+	issuedAt, err := token.Claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return false
+	}
+	return issuedAt.Before(k.options.LegacyIssuedBefore)
+}
+
+// JWKS returns the public keys of every unexpired asymmetric key. HS256
+// secrets are never published.
+func (k *Keyring) JWKS() JWKS {
+	now := time.Now()
+	jwks := JWKS{Keys: make([]JWK, 0, len(k.keys))}
+
+	for _, key := range k.keys {
+		if key.Algorithm == AlgHS256 || key.expired(now) {
+			continue
+		}
+
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = encodeSegment(publicKey.N.Bytes())
+			jwk.E = encodeSegment(big.NewInt(int64(publicKey.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (publicKey.Curve.Params().BitSize + 7) / 8
+			jwk.KeyType = "EC"
+			jwk.Curve = publicKey.Curve.Params().Name
+			jwk.X = encodeSegment(publicKey.X.FillBytes(make([]byte, size)))
+			jwk.Y = encodeSegment(publicKey.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = encodeSegment(publicKey)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	// Keep the output stable between requests
+	slices.SortFunc(jwks.Keys, func(a, b JWK) int {
+		return strings.Compare(a.KeyID, b.KeyID)
+	})
+
+	return jwks
+}
+
+// check makes sure the key material matches the algorithm.
+func (key Key) check() error {
+	switch key.Algorithm {
+	case AlgHS256:
+		if len(key.Secret) == 0 {
+			return errors.New("secret cannot be empty")
+		}
+		return nil
+	case AlgRS256:
+		if _, ok := key.PublicKey.(*rsa.PublicKey); !ok {
+			return errors.New("RS256 needs an RSA key")
+		}
+	case AlgES256:
+		publicKey, ok := key.PublicKey.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve != elliptic.P256() {
+			return errors.New("ES256 needs a P-256 ECDSA key")
+		}
+	case AlgEdDSA:
+		if _, ok := key.PublicKey.(ed25519.PublicKey); !ok {
+			return errors.New("EdDSA needs an Ed25519 key")
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q", key.Algorithm)
+	}
+
+	if len(key.Secret) > 0 {
+		return fmt.Errorf("%s keys don't take a secret", key.Algorithm)
+	}
+	return nil
+}
+
+// publicKeyMatches reports whether publicKey is the public half of
+// privateKey. Every key type the standard library parses has an Equal method.
+func publicKeyMatches(privateKey crypto.Signer, publicKey crypto.PublicKey) bool {
+	derived, ok := privateKey.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && derived.Equal(publicKey)
+}
+
+func (key Key) expired(now time.Time) bool {
+	return !key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// readPrivateKey reads a PKCS #8, PKCS #1 (RSA) or SEC 1 (EC) PEM private key.
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var privateKey interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s does not hold a signing key", path)
+	}
+	return signer, nil
+}
+
+// readPublicKey reads a PKIX PEM public key.
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return publicKey, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,514 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+
+	return testKeys{rsa: rsaKey, ecdsa: ecdsaKey, ed25519: ed25519Key}
+}
+
+func TestKeyringSignAndValidate(t *testing.T) {
+	keys := newTestKeys(t)
+
+	tests := []struct {
+		name string
+		key  Key
+	}{
+		{"HS256", Key{ID: "hmac", Algorithm: AlgHS256, Secret: []byte("mysecret")}},
+		{"RS256", Key{ID: "rsa", Algorithm: AlgRS256, PrivateKey: keys.rsa}},
+		{"ES256", Key{ID: "ecdsa", Algorithm: AlgES256, PrivateKey: keys.ecdsa}},
+		{"EdDSA", Key{ID: "ed25519", Algorithm: AlgEdDSA, PrivateKey: keys.ed25519}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring([]Key{tt.key}, tt.key.ID)
+			if err != nil {
+				t.Fatalf("NewKeyring() error = %v", err)
+			}
+
+			userID := uuid.New()
+			token, err := MakeJWT(userID, keyring, time.Hour)
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified() error = %v", err)
+			}
+			if parsed.Header["kid"] != tt.key.ID || parsed.Header["alg"] != tt.key.Algorithm {
+				t.Errorf("MakeJWT() header = %v, want kid %v and alg %v", parsed.Header, tt.key.ID, tt.key.Algorithm)
+			}
+
+			gotUserID, err := ValidateJWT(token, keyring)
+			if err != nil {
+				t.Fatalf("ValidateJWT() error = %v", err)
+			}
+			if gotUserID != userID {
+				t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotUserID, userID)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	keys := newTestKeys(t)
+	oldKey := Key{ID: "old", Algorithm: AlgHS256, Secret: []byte("mysecret")}
+	newKey := Key{ID: "new", Algorithm: AlgES256, PrivateKey: keys.ecdsa}
+
+	oldKeyring, err := NewKeyring([]Key{oldKey}, "old")
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	oldToken, _ := MakeJWT(uuid.New(), oldKeyring, time.Hour)
+
+	retiredKey := oldKey
+	retiredKey.ExpiresAt = time.Now().Add(time.Hour)
+	expiredKey := oldKey
+	expiredKey.ExpiresAt = time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		keys    []Key
+		wantErr bool
+	}{
+		{"RetiredKeyAccepted", []Key{newKey, retiredKey}, false},
+		{"ExpiredKeyRejected", []Key{newKey, expiredKey}, true},
+		{"RemovedKeyRejected", []Key{newKey}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.keys, "new")
+			if err != nil {
+				t.Fatalf("NewKeyring() error = %v", err)
+			}
+
+			_, err = ValidateJWT(oldToken, keyring)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// New tokens are signed with the active key
+			newToken, _ := MakeJWT(uuid.New(), keyring, time.Hour)
+			_, err = ValidateJWT(newToken, keyring)
+			if err != nil {
+				t.Errorf("ValidateJWT() error = %v for token signed by active key", err)
+			}
+		})
+	}
+}
+
+func TestKeyringRejectsForgedTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	keyring, err := NewKeyring([]Key{
+		{ID: "rsa", Algorithm: AlgRS256, PrivateKey: keys.rsa},
+		{ID: "hmac", Algorithm: AlgHS256, Secret: []byte("mysecret")},
+	}, "rsa")
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	err = keyring.SetValidationOptions(ValidationOptions{LegacyIssuedBefore: time.Now().Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("SetValidationOptions() error = %v", err)
+	}
+
+	publicDER, _ := x509.MarshalPKIXPublicKey(&keys.rsa.PublicKey)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	claims := jwt.RegisteredClaims{
+		Issuer:    "chirpy",
+		Subject:   uuid.New().String(),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		ID:        uuid.NewString(),
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if len(kid) > 0 {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString() error = %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name        string
+		tokenString string
+		wantErr     bool
+	}{
+		{"PublicKeyAsHMACSecret", sign(jwt.SigningMethodHS256, "rsa", publicPEM), true},
+		{"PublicKeyDERAsHMACSecret", sign(jwt.SigningMethodHS256, "rsa", publicDER), true},
+		{"UnknownKeyID", sign(jwt.SigningMethodHS256, "other", []byte("mysecret")), true},
+		{"NoneAlgorithm", sign(jwt.SigningMethodNone, "hmac", jwt.UnsafeAllowNoneSignatureType), true},
+		{"MissingKeyIDAsymmetric", sign(jwt.SigningMethodRS256, "", keys.rsa), true},
+		{"MissingKeyIDLegacyHMAC", sign(jwt.SigningMethodHS256, "", []byte("mysecret")), false},
+		{"ValidToken", sign(jwt.SigningMethodHS256, "hmac", []byte("mysecret")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateJWT(tt.tokenString, keyring)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	keys := newTestKeys(t)
+	hmacKey := Key{ID: "hmac", Algorithm: AlgHS256, Secret: []byte("mysecret")}
+
+	tests := []struct {
+		name     string
+		keys     []Key
+		activeID string
+		wantErr  bool
+	}{
+		{"ValidKeyring", []Key{hmacKey, {ID: "rsa", Algorithm: AlgRS256, PrivateKey: keys.rsa}}, "hmac", false},
+		{"VerifyOnlyRetiredKey", []Key{hmacKey, {ID: "ecdsa", Algorithm: AlgES256, PublicKey: keys.ecdsa.Public()}}, "hmac", false},
+		{"EmptyKeyID", []Key{{Algorithm: AlgHS256, Secret: []byte("mysecret")}}, "", true},
+		{"DuplicateKeyID", []Key{hmacKey, hmacKey}, "hmac", true},
+		{"EmptySecret", []Key{{ID: "hmac", Algorithm: AlgHS256}}, "hmac", true},
+		{"UnsupportedAlgorithm", []Key{{ID: "hmac", Algorithm: "HS512", Secret: []byte("mysecret")}}, "hmac", true},
+		{"MatchingPublicKey", []Key{{ID: "ecdsa", Algorithm: AlgES256, PrivateKey: keys.ecdsa, PublicKey: keys.ecdsa.Public()}}, "ecdsa", false},
+		{"MismatchedPublicKey", []Key{{ID: "ecdsa", Algorithm: AlgES256, PrivateKey: keys.ecdsa, PublicKey: mustECDSAKey(t, elliptic.P256()).Public()}}, "ecdsa", true},
+		{"MismatchedPublicKeyType", []Key{{ID: "ed25519", Algorithm: AlgEdDSA, PrivateKey: keys.ed25519, PublicKey: keys.rsa.Public()}}, "ed25519", true},
+		{"WrongKeyType", []Key{{ID: "rsa", Algorithm: AlgRS256, PrivateKey: keys.ecdsa}}, "rsa", true},
+		{"WrongCurve", []Key{{ID: "ecdsa", Algorithm: AlgES256, PublicKey: mustECDSAKey(t, elliptic.P384()).Public()}}, "hmac", true},
+		{"ActiveKeyMissing", []Key{hmacKey}, "other", true},
+		{"ActiveKeyVerifyOnly", []Key{{ID: "ed25519", Algorithm: AlgEdDSA, PublicKey: keys.ed25519.Public()}}, "ed25519", true},
+		{"ActiveKeyExpired", []Key{{ID: "hmac", Algorithm: AlgHS256, Secret: []byte("mysecret"), ExpiresAt: time.Now().Add(-time.Minute)}}, "hmac", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.keys, tt.activeID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeyring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustECDSAKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	return key
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	keys := newTestKeys(t)
+	keyring, err := NewKeyring([]Key{
+		{ID: "a-rsa", Algorithm: AlgRS256, PrivateKey: keys.rsa},
+		{ID: "b-ecdsa", Algorithm: AlgES256, PublicKey: keys.ecdsa.Public()},
+		{ID: "c-ed25519", Algorithm: AlgEdDSA, PrivateKey: keys.ed25519},
+		{ID: "d-hmac", Algorithm: AlgHS256, Secret: []byte("mysecret")},
+		{ID: "e-expired", Algorithm: AlgEdDSA, PrivateKey: keys.ed25519, ExpiresAt: time.Now().Add(-time.Minute)},
+	}, "a-rsa")
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	decode := func(segment string) []byte {
+		data, err := base64.RawURLEncoding.DecodeString(segment)
+		if err != nil {
+			t.Fatalf("DecodeString() error = %v", err)
+		}
+		return data
+	}
+
+	jwks := keyring.JWKS()
+	if len(jwks.Keys) != 3 {
+		t.Fatalf("JWKS() got %v keys, want 3", len(jwks.Keys))
+	}
+
+	rsaJWK := jwks.Keys[0]
+	if rsaJWK.KeyID != "a-rsa" || rsaJWK.KeyType != "RSA" || rsaJWK.Algorithm != AlgRS256 || rsaJWK.Use != "sig" {
+		t.Errorf("JWKS() got RSA key %+v", rsaJWK)
+	}
+	if rsaJWK.E != "AQAB" || string(decode(rsaJWK.N)) != string(keys.rsa.N.Bytes()) {
+		t.Errorf("JWKS() got RSA modulus or exponent that doesn't match the key")
+	}
+
+	ecJWK := jwks.Keys[1]
+	if ecJWK.KeyID != "b-ecdsa" || ecJWK.KeyType != "EC" || ecJWK.Curve != "P-256" {
+		t.Errorf("JWKS() got EC key %+v", ecJWK)
+	}
+	if len(decode(ecJWK.X)) != 32 || len(decode(ecJWK.Y)) != 32 {
+		t.Errorf("JWKS() got EC coordinates that aren't 32 bytes")
+	}
+
+	okpJWK := jwks.Keys[2]
+	if okpJWK.KeyID != "c-ed25519" || okpJWK.KeyType != "OKP" || okpJWK.Curve != "Ed25519" {
+		t.Errorf("JWKS() got OKP key %+v", okpJWK)
+	}
+	if string(decode(okpJWK.X)) != string(keys.ed25519.Public().(ed25519.PublicKey)) {
+		t.Errorf("JWKS() got OKP key that doesn't match the key")
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	keys := newTestKeys(t)
+	dir := t.TempDir()
+
+	writePEM := func(name, blockType string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		err := os.WriteFile(filepath.Join(dir, name), data, 0600)
+		if err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	ed25519DER, _ := x509.MarshalPKCS8PrivateKey(keys.ed25519)
+	writePEM("ed25519.pem", "PRIVATE KEY", ed25519DER)
+	writePEM("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(keys.rsa))
+	ecdsaDER, _ := x509.MarshalPKIXPublicKey(keys.ecdsa.Public())
+	writePEM("ecdsa.pub.pem", "PUBLIC KEY", ecdsaDER)
+
+	keyringJSON := `{
+		"active": "ed25519",
+		"keys": [
+			{"kid": "ed25519", "alg": "EdDSA", "private_key_file": "ed25519.pem"},
+			{"kid": "rsa", "alg": "RS256", "private_key_file": "rsa.pem"},
+			{"kid": "ecdsa", "alg": "ES256", "public_key_file": "ecdsa.pub.pem", "expires_at": "2100-01-01T00:00:00Z"},
+			{"kid": "hmac", "alg": "HS256", "secret": "mysecret"}
+		]
+	}`
+	keyringPath := filepath.Join(dir, "keys.json")
+	err := os.WriteFile(keyringPath, []byte(keyringJSON), 0600)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		activeID   string
+		wantActive string
+		wantErr    bool
+	}{
+		{"ActiveFromFile", "", "ed25519", false},
+		{"ActiveOverridden", "rsa", "rsa", false},
+		{"VerifyOnlyActive", "ecdsa", "", true},
+		{"UnknownActive", "other", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := LoadKeyring(keyringPath, tt.activeID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadKeyring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if keyring.ActiveKeyID() != tt.wantActive {
+				t.Errorf("LoadKeyring() active = %v, want %v", keyring.ActiveKeyID(), tt.wantActive)
+			}
+
+			token, err := MakeJWT(uuid.New(), keyring, time.Hour)
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+			_, err = ValidateJWT(token, keyring)
+			if err != nil {
+				t.Errorf("ValidateJWT() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyringValidationOptions(t *testing.T) {
+	keys := newTestKeys(t)
+	newKeyring := func(options ValidationOptions) *Keyring {
+		keyring, err := NewKeyring([]Key{
+			{ID: "hmac", Algorithm: AlgHS256, Secret: []byte("mysecret")},
+			{ID: "ecdsa", Algorithm: AlgES256, PrivateKey: keys.ecdsa},
+		}, "hmac")
+		if err != nil {
+			t.Fatalf("NewKeyring() error = %v", err)
+		}
+		err = keyring.SetValidationOptions(options)
+		if err != nil {
+			t.Fatalf("SetValidationOptions() error = %v", err)
+		}
+		return keyring
+	}
+	defaultKeyring := newKeyring(ValidationOptions{})
+
+	// Sign claims directly so each test can leave out or change one of them
+	claims := func(change func(*jwt.RegisteredClaims)) jwt.RegisteredClaims {
+		c := jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			Subject:   uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			ID:        uuid.NewString(),
+		}
+		if change != nil {
+			change(&c)
+		}
+		return c
+	}
+	sign := func(c jwt.RegisteredClaims) string {
+		token, err := defaultKeyring.Sign(c)
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return token
+	}
+
+	// Tokens issued before kid headers had no ID either
+	signLegacy := func(c jwt.RegisteredClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte("mysecret"))
+		if err != nil {
+			t.Fatalf("SignedString() error = %v", err)
+		}
+		return token
+	}
+
+	audienceKeyring := newKeyring(ValidationOptions{Audience: "chirpy-api"})
+	legacyKeyring := newKeyring(ValidationOptions{LegacyIssuedBefore: time.Now().Add(time.Minute)})
+	audienceToken, _ := MakeJWT(uuid.New(), audienceKeyring, time.Hour)
+	recentlyExpired := claims(func(c *jwt.RegisteredClaims) {
+		c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+	})
+
+	tests := []struct {
+		name        string
+		tokenString string
+		keyring     *Keyring
+		wantErr     bool
+	}{
+		{"ValidToken", sign(claims(nil)), defaultKeyring, false},
+		{"WrongIssuer", sign(claims(func(c *jwt.RegisteredClaims) { c.Issuer = "other" })), defaultKeyring, true},
+		{"CustomIssuer", sign(claims(func(c *jwt.RegisteredClaims) { c.Issuer = "other" })), newKeyring(ValidationOptions{Issuer: "other"}), false},
+		{"MissingID", sign(claims(func(c *jwt.RegisteredClaims) { c.ID = "" })), defaultKeyring, true},
+		{"MissingIDLegacy", signLegacy(claims(func(c *jwt.RegisteredClaims) { c.ID = "" })), legacyKeyring, false},
+		{"LegacyWithoutCutoff", signLegacy(claims(func(c *jwt.RegisteredClaims) { c.ID = "" })), defaultKeyring, true},
+		{"LegacyIssuedAfterCutoff", signLegacy(claims(func(c *jwt.RegisteredClaims) { c.ID = "" })), newKeyring(ValidationOptions{LegacyIssuedBefore: time.Now().Add(-time.Minute)}), true},
+		{"LegacyWithoutIssuedAt", signLegacy(claims(func(c *jwt.RegisteredClaims) { c.ID, c.IssuedAt = "", nil })), legacyKeyring, true},
+		{"WrongIssuerLegacy", signLegacy(claims(func(c *jwt.RegisteredClaims) { c.Issuer = "other" })), legacyKeyring, true},
+		{"MissingExpiry", sign(claims(func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil })), defaultKeyring, true},
+		{"IssuedInFuture", sign(claims(func(c *jwt.RegisteredClaims) { c.IssuedAt = jwt.NewNumericDate(time.Now().Add(time.Hour)) })), defaultKeyring, true},
+		{"MissingAudience", sign(claims(nil)), audienceKeyring, true},
+		{"WrongAudience", sign(claims(func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"other"} })), audienceKeyring, true},
+		{"IssuedWithAudience", audienceToken, audienceKeyring, false},
+		{"AlgorithmNotAllowed", sign(claims(nil)), newKeyring(ValidationOptions{Algorithms: []string{AlgES256}}), true},
+		{"ExpiredWithoutLeeway", sign(recentlyExpired), defaultKeyring, true},
+		{"ExpiredWithinLeeway", sign(recentlyExpired), newKeyring(ValidationOptions{Leeway: 30 * time.Second}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAccessToken(tt.tokenString, tt.keyring)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetValidationOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ValidationOptions
+		wantErr bool
+	}{
+		{"DefaultOptions", ValidationOptions{}, false},
+		{"AllowedAlgorithm", ValidationOptions{Algorithms: []string{AlgHS256}}, false},
+		{"AlgorithmWithoutKey", ValidationOptions{Algorithms: []string{AlgRS256}}, true},
+		{"NegativeLeeway", ValidationOptions{Leeway: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, _ := NewHMACKeyring("hmac", "mysecret")
+			err := keyring.SetValidationOptions(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetValidationOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseAccessToken(t *testing.T) {
+	keyring, _ := NewHMACKeyring("hmac", "mysecret")
+	userID := uuid.New()
+
+	first, _ := MakeJWT(userID, keyring, time.Hour)
+	second, _ := MakeJWT(userID, keyring, time.Hour)
+
+	firstToken, err := ParseAccessToken(first, keyring)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	secondToken, err := ParseAccessToken(second, keyring)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+
+	if firstToken.UserID != userID {
+		t.Errorf("ParseAccessToken() gotUserID = %v, want %v", firstToken.UserID, userID)
+	}
+	if len(firstToken.ID) == 0 || firstToken.ID == secondToken.ID {
+		t.Errorf("ParseAccessToken() got IDs %q and %q, want unique IDs", firstToken.ID, secondToken.ID)
+	}
+	if firstToken.SessionID != uuid.Nil {
+		t.Errorf("ParseAccessToken() gotSessionID = %v, want %v", firstToken.SessionID, uuid.Nil)
+	}
+
+	// Tokens issued for a session carry its ID
+	sessionID := uuid.New()
+	sessionJWT, _ := MakeSessionJWT(userID, sessionID, keyring, time.Hour)
+	sessionToken, err := ParseAccessToken(sessionJWT, keyring)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	if sessionToken.SessionID != sessionID {
+		t.Errorf("ParseAccessToken() gotSessionID = %v, want %v", sessionToken.SessionID, sessionID)
+	}
+}
@@ -7,23 +7,52 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const chirpIsReferenced = `-- name: ChirpIsReferenced :one
+SELECT EXISTS (
+    SELECT 1
+    FROM chirps
+    WHERE parent_id = $1
+       OR original_id = $1
+) AS is_referenced
+`
+
+func (q *Queries) ChirpIsReferenced(ctx context.Context, parentID uuid.NullUUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, chirpIsReferenced, parentID)
+	var is_referenced bool
+	err := row.Scan(&is_referenced)
+	return is_referenced, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), now(), now(), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, thread_id, kind, original_id)
+VALUES (gen_random_uuid(), now(), now(), $1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	ParentID   uuid.NullUUID
+	ThreadID   uuid.NullUUID
+	Kind       string
+	OriginalID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ParentID,
+		arg.ThreadID,
+		arg.Kind,
+		arg.OriginalID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +60,11 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.OriginalID,
 	)
 	return i, err
 }
@@ -46,7 +80,7 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 }
 
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
 FROM chirps
 WHERE id = $1
 LIMIT 1
@@ -61,19 +95,193 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.OriginalID,
+	)
+	return i, err
+}
+
+const getChirpByIdForUpdate = `-- name: GetChirpByIdForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE id = $1
+LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpByIdForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByIdForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.OriginalID,
 	)
 	return i, err
 }
 
+const getChirpReplies = `-- name: GetChirpReplies :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE parent_id = $1
+  AND ($2::timestamp IS NULL
+   OR ($3::text = 'asc' AND (created_at, id) > ($2::timestamp, $4::uuid))
+   OR ($3::text = 'desc' AND (created_at, id) < ($2::timestamp, $4::uuid)))
+ORDER BY CASE WHEN $3::text = 'desc' THEN created_at END DESC,
+         CASE WHEN $3::text = 'desc' THEN id END DESC,
+         CASE WHEN $3::text = 'asc' THEN created_at END ASC,
+         CASE WHEN $3::text = 'asc' THEN id END ASC
+LIMIT $5
+`
+
+type GetChirpRepliesParams struct {
+	ParentID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	Sort            string
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetChirpReplies(ctx context.Context, arg GetChirpRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpReplies,
+		arg.ParentID,
+		arg.CursorCreatedAt,
+		arg.Sort,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.OriginalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpThread = `-- name: GetChirpThread :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE id = $1
+   OR thread_id = $1
+ORDER BY created_at ASC, id ASC
+`
+
+func (q *Queries) GetChirpThread(ctx context.Context, threadID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpThread, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.OriginalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
 FROM chirps
-ORDER BY CASE WHEN $1 = 'desc' THEN created_at END DESC,
-         CASE WHEN $1 = 'asc' THEN created_at END ASC
+WHERE deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1
+      FROM blocks
+      WHERE blocker_id = $1
+        AND blocked_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM mutes
+      WHERE muter_id = $1
+        AND muted_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND ($2::timestamp IS NULL
+   OR ($3::text = 'asc' AND (created_at, id) > ($2::timestamp, $4::uuid))
+   OR ($3::text = 'desc' AND (created_at, id) < ($2::timestamp, $4::uuid)))
+ORDER BY CASE WHEN $3::text = 'desc' THEN created_at END DESC,
+         CASE WHEN $3::text = 'desc' THEN id END DESC,
+         CASE WHEN $3::text = 'asc' THEN created_at END ASC,
+         CASE WHEN $3::text = 'asc' THEN id END ASC
+LIMIT $5
 `
 
-func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps, dollar_1)
+type GetChirpsParams struct {
+	ViewerID        uuid.UUID
+	CursorCreatedAt sql.NullTime
+	Sort            string
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetChirps(ctx context.Context, arg GetChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.Sort,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +295,51 @@ func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp,
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.OriginalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetChirpsByIds(ctx context.Context, chirpIds []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.OriginalID,
 		); err != nil {
 			return nil, err
 		}
@@ -102,20 +355,219 @@ func (q *Queries) GetChirps(ctx context.Context, dollar_1 interface{}) ([]Chirp,
 }
 
 const getChirpsByUserId = `-- name: GetChirpsByUserId :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
 FROM chirps
 WHERE user_id = $1
-ORDER BY CASE WHEN $2 = 'desc' THEN created_at END DESC,
-         CASE WHEN $2 = 'asc' THEN created_at END ASC
+  AND deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1
+      FROM pinned_chirps
+      WHERE chirp_id = chirps.id
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM blocks
+      WHERE blocker_id = $2
+        AND blocked_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM mutes
+      WHERE muter_id = $2
+        AND muted_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND ($3::timestamp IS NULL
+   OR ($4::text = 'asc' AND (created_at, id) > ($3::timestamp, $5::uuid))
+   OR ($4::text = 'desc' AND (created_at, id) < ($3::timestamp, $5::uuid)))
+ORDER BY CASE WHEN $4::text = 'desc' THEN created_at END DESC,
+         CASE WHEN $4::text = 'desc' THEN id END DESC,
+         CASE WHEN $4::text = 'asc' THEN created_at END ASC,
+         CASE WHEN $4::text = 'asc' THEN id END ASC
+LIMIT $6
 `
 
 type GetChirpsByUserIdParams struct {
-	UserID  uuid.UUID
-	Column2 interface{}
+	UserID          uuid.UUID
+	ViewerID        uuid.UUID
+	CursorCreatedAt sql.NullTime
+	Sort            string
+	CursorID        uuid.NullUUID
+	PageLimit       int32
 }
 
 func (q *Queries) GetChirpsByUserId(ctx context.Context, arg GetChirpsByUserIdParams) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserId, arg.UserID, arg.Column2)
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserId,
+		arg.UserID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.Sort,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.OriginalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRechirpForUpdate = `-- name: GetRechirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE user_id = $1
+  AND original_id = $2
+  AND kind = 'rechirp'
+  AND deleted_at IS NULL
+LIMIT 1
+FOR UPDATE
+`
+
+type GetRechirpForUpdateParams struct {
+	UserID     uuid.UUID
+	OriginalID uuid.NullUUID
+}
+
+func (q *Queries) GetRechirpForUpdate(ctx context.Context, arg GetRechirpForUpdateParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getRechirpForUpdate, arg.UserID, arg.OriginalID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.OriginalID,
+	)
+	return i, err
+}
+
+const getReplyCounts = `-- name: GetReplyCounts :many
+SELECT parent_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE parent_id = ANY($1::uuid[])
+  AND deleted_at IS NULL
+GROUP BY parent_id
+`
+
+type GetReplyCountsRow struct {
+	ParentID   uuid.NullUUID
+	ReplyCount int64
+}
+
+func (q *Queries) GetReplyCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetReplyCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReplyCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReplyCountsRow
+	for rows.Next() {
+		var i GetReplyCountsRow
+		if err := rows.Scan(
+			&i.ParentID,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTimelineChirps = `-- name: GetTimelineChirps :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE user_id IN (
+    SELECT followee_id
+    FROM follows
+    WHERE follower_id = $1
+)
+  AND deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1
+      FROM blocks
+      WHERE blocker_id = $1
+        AND blocked_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM mutes
+      WHERE muter_id = $1
+        AND muted_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND ($2::timestamp IS NULL
+   OR ($3::text = 'asc' AND (created_at, id) > ($2::timestamp, $4::uuid))
+   OR ($3::text = 'desc' AND (created_at, id) < ($2::timestamp, $4::uuid)))
+ORDER BY CASE WHEN $3::text = 'desc' THEN created_at END DESC,
+         CASE WHEN $3::text = 'desc' THEN id END DESC,
+         CASE WHEN $3::text = 'asc' THEN created_at END ASC,
+         CASE WHEN $3::text = 'asc' THEN id END ASC
+LIMIT $5
+`
+
+type GetTimelineChirpsParams struct {
+	FollowerID      uuid.UUID
+	CursorCreatedAt sql.NullTime
+	Sort            string
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetTimelineChirps(ctx context.Context, arg GetTimelineChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTimelineChirps,
+		arg.FollowerID,
+		arg.CursorCreatedAt,
+		arg.Sort,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +581,11 @@ func (q *Queries) GetChirpsByUserId(ctx context.Context, arg GetChirpsByUserIdPa
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ParentID,
+			&i.ThreadID,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.OriginalID,
 		); err != nil {
 			return nil, err
 		}
@@ -142,3 +599,47 @@ func (q *Queries) GetChirpsByUserId(ctx context.Context, arg GetChirpsByUserIdPa
 	}
 	return items, nil
 }
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET updated_at = now(),
+    body = '',
+    deleted_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET updated_at = now(),
+    body = $1
+WHERE id = $2
+RETURNING id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.ThreadID,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.OriginalID,
+	)
+	return i, err
+}
@@ -11,21 +11,186 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID       uuid.UUID
+	ChirpID      uuid.UUID
+	CreatedAt    time.Time
+	CollectionID uuid.NullUUID
+}
+
+type BookmarkCollection struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
 type Chirp struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	ParentID   uuid.NullUUID
+	ThreadID   uuid.NullUUID
+	DeletedAt  sql.NullTime
+	Kind       string
+	OriginalID uuid.NullUUID
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMedium struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type ChirpMention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
+	ChirpID   uuid.UUID
 	Body      string
+}
+
+type Conversation struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	CreatedBy      uuid.NullUUID
+	DirectUserLow  uuid.NullUUID
+	DirectUserHigh uuid.NullUUID
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type Draft struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	Body           string
+	ParentID       uuid.NullUUID
+	PublishAt      sql.NullTime
+	FailedAttempts int32
+	NextAttemptAt  sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ContentType  string
+	SizeBytes    int64
+	Width        int32
+	Height       int32
+	StorageKey   string
+	ThumbnailKey string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
 	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Kind      string
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
 }
 
-type RefreshToken struct {
-	Token     string
+type PinnedChirp struct {
+	UserID   uuid.UUID
+	ChirpID  uuid.UUID
+	PinnedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+	ClosedAt  sql.NullTime
+}
+
+type PollOption struct {
+	ChirpID    uuid.UUID
+	Position   int32
+	Label      string
+	FinalVotes int64
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Position  int32
 	CreatedAt time.Time
-	UpdatedAt time.Time
+}
+
+type RefreshToken struct {
+	TokenHash     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UserID        uuid.UUID
+	ExpiresAt     time.Time
+	RevokedAt     sql.NullTime
+	FamilyID      uuid.UUID
+	UserAgent     string
+	IpAddress     string
+	LastUsedAt    time.Time
+	RevokedReason sql.NullString
+}
+
+type RevokedAccessToken struct {
+	TokenID   string
 	UserID    uuid.UUID
+	RevokedAt time.Time
 	ExpiresAt time.Time
-	RevokedAt sql.NullTime
 }
 
 type User struct {
@@ -35,4 +200,10 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	Location       string
+	Website        string
+	AvatarUrl      string
 }
@@ -7,23 +7,39 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countUsersByIds = `-- name: CountUsersByIds :one
+SELECT COUNT(*) AS user_count
+FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) CountUsersByIds(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsersByIds, pq.Array(ids))
+	var user_count int64
+	err := row.Scan(&user_count)
+	return user_count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
-VALUES (gen_random_uuid(), now(), now(), $1, $2)
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
+VALUES (gen_random_uuid(), now(), now(), $1, $2, $3)
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, website, avatar_url
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -32,12 +48,59 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
 
+const getMentionableUserIDs = `-- name: GetMentionableUserIDs :many
+SELECT id
+FROM users
+WHERE lower(handle) = ANY($1::text[])
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks
+    WHERE blocker_id = users.id
+      AND blocked_id = $2
+)
+`
+
+type GetMentionableUserIDsParams struct {
+	Handles  []string
+	AuthorID uuid.UUID
+}
+
+func (q *Queries) GetMentionableUserIDs(ctx context.Context, arg GetMentionableUserIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getMentionableUserIDs, pq.Array(arg.Handles), arg.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle,
+       display_name, bio, location, website, avatar_url
 FROM users
 WHERE email = $1
 LIMIT 1
@@ -53,12 +116,47 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarUrl,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle,
+       display_name, bio, location, website, avatar_url
+FROM users
+WHERE lower(handle) = lower($1)
+LIMIT 1
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle,
+       display_name, bio, location, website, avatar_url
 FROM users
 WHERE id = $1
 LIMIT 1
@@ -74,6 +172,63 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarUrl,
+	)
+	return i, err
+}
+
+const getUserProfileCounts = `-- name: GetUserProfileCounts :one
+SELECT (SELECT COUNT(*) FROM follows WHERE followee_id = $1) AS follower_count,
+       (SELECT COUNT(*) FROM follows WHERE follower_id = $1) AS following_count,
+       (SELECT COUNT(*) FROM chirps WHERE user_id = $1 AND deleted_at IS NULL) AS chirp_count
+`
+
+type GetUserProfileCountsRow struct {
+	FollowerCount  int64
+	FollowingCount int64
+	ChirpCount     int64
+}
+
+func (q *Queries) GetUserProfileCounts(ctx context.Context, id uuid.UUID) (GetUserProfileCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfileCounts, id)
+	var i GetUserProfileCountsRow
+	err := row.Scan(
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.ChirpCount,
+	)
+	return i, err
+}
+
+const lockUser = `-- name: LockUser :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle,
+       display_name, bio, location, website, avatar_url
+FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, lockUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
@@ -91,19 +246,77 @@ const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET updated_at = now(),
     email = $1,
-    hashed_password = $2
-WHERE id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    hashed_password = $2,
+    handle = COALESCE($3, handle)
+WHERE id = $4
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, website, avatar_url
 `
 
 type UpdateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 	ID             uuid.UUID
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.Email, arg.HashedPassword, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarUrl,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET updated_at = now(),
+    handle = COALESCE($1, handle),
+    display_name = COALESCE($2, display_name),
+    bio = COALESCE($3, bio),
+    location = COALESCE($4, location),
+    website = COALESCE($5, website),
+    avatar_url = COALESCE($6, avatar_url)
+WHERE id = $7
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, website, avatar_url
+`
+
+type UpdateUserProfileParams struct {
+	Handle      sql.NullString
+	DisplayName sql.NullString
+	Bio         sql.NullString
+	Location    sql.NullString
+	Website     sql.NullString
+	AvatarUrl   sql.NullString
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Location,
+		arg.Website,
+		arg.AvatarUrl,
+		arg.ID,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -112,6 +325,12 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
@@ -121,7 +340,7 @@ UPDATE users
 SET updated_at = now(),
     is_chirpy_red = true
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, location, website, avatar_url
 `
 
 func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -134,6 +353,12 @@ func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (Use
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/uploads/")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	ctx := context.Background()
+	data := []byte("hello")
+
+	err = store.Put(ctx, "media/a.png", bytes.NewReader(data), int64(len(data)), "image/png")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	body, err := store.Get(ctx, "media/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(got, data) {
+		t.Errorf("Expected %q but got %q", data, got)
+	}
+
+	if url := store.URL("media/a.png"); url != "/uploads/media/a.png" {
+		t.Errorf("Expected /uploads/media/a.png but got %q", url)
+	}
+
+	err = store.Delete(ctx, "media/a.png")
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	_, err = store.Get(ctx, "media/a.png")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got %v", err)
+	}
+
+	// Keys can't escape the root directory
+	for _, key := range []string{"../a.png", "media/../../a.png", "/a.png", ""} {
+		err = store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/png")
+		if err == nil {
+			t.Errorf("Expected error for key %q", key)
+		}
+	}
+}
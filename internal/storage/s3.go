@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+// S3Store keeps objects in a bucket of an S3-compatible service (AWS S3,
+// MinIO, R2, ...) using path-style requests signed with Signature Version 4.
+type S3Store struct {
+	Endpoint        string // e.g. https://s3.us-east-1.amazonaws.com
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PublicURL is where the bucket is readable by clients. Defaults to the
+	// bucket URL on Endpoint.
+	PublicURL string
+	Client    *http.Client
+
+	now func() time.Time
+}
+
+func (s *S3Store) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+func (s *S3Store) objectURL(key string) string {
+	return strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + escapePath(key)
+}
+
+func (s *S3Store) do(ctx context.Context, method, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	rq, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		rq.ContentLength = size
+	}
+	if len(contentType) > 0 {
+		rq.Header.Set("Content-Type", contentType)
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	signRequest(rq, s.AccessKeyID, s.SecretAccessKey, s.Region, now().UTC(), s3UnsignedPayload)
+
+	return s.client().Do(rq)
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, body, size, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, s3Error(resp)
+	}
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Deleting a missing object is not an error
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (s *S3Store) URL(key string) string {
+	if len(s.PublicURL) > 0 {
+		return strings.TrimSuffix(s.PublicURL, "/") + "/" + escapePath(key)
+	}
+	return s.objectURL(key)
+}
+
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// escapePath percent-encodes every byte of path except unreserved characters
+// and slashes, as Signature Version 4 requires.
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// signRequest adds Signature Version 4 headers to rq. Every header already
+// set on rq is signed along with the host.
+func signRequest(rq *http.Request, accessKeyID, secretAccessKey, region string, now time.Time, payloadHash string) {
+	rq.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	rq.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonical, signedHeaders := canonicalRequest(rq, payloadHash)
+	scope := now.Format(s3DateFormat) + "/" + region + "/s3/aws4_request"
+	stringToSign := s3Algorithm + "\n" + now.Format(s3TimeFormat) + "\n" + scope + "\n" + sha256Hex(canonical)
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	rq.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalRequest builds the Signature Version 4 canonical form of rq and
+// the list of headers it signs.
+func canonicalRequest(rq *http.Request, payloadHash string) (string, string) {
+	host := rq.Host
+	if len(host) == 0 {
+		host = rq.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for name, values := range rq.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := rq.URL.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+
+	canonical := strings.Join([]string{
+		rq.Method,
+		path,
+		canonicalQuery(rq.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	return canonical, signedHeaders
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, escapePath(key)+"="+strings.ReplaceAll(escapePath(value), "/", "%2F"))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
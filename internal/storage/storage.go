@@ -0,0 +1,21 @@
+// Package storage keeps uploaded files behind a common interface so they can
+// live on the local filesystem or in an S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Store saves, loads and deletes objects by key. Keys use forward slashes,
+// e.g. "media/<id>.png".
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns where clients can download the object.
+	URL(key string) string
+}
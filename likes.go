@@ -0,0 +1,263 @@
+package main
+
+import (
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"time"
+)
+
+type likedChirp struct {
+	Chirp   Chirp
+	LikedAt time.Time
+}
+
+func likedChirpCursor(liked likedChirp) pageCursor {
+	return pageCursor{CreatedAt: liked.LikedAt, ID: liked.Chirp.ID}
+}
+
+func (cfg *apiConfig) likeChirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure chirp exists
+	dbChirp, err := cfg.db.GetChirpById(rq.Context(), id)
+	if err != nil || dbChirp.DeletedAt.Valid {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if the chirp author blocked the user
+	blockParams := database.IsBlockedParams{
+		BlockerID: dbChirp.UserID,
+		BlockedID: userID,
+	}
+
+	blocked, err := cfg.db.IsBlocked(rq.Context(), blockParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error liking chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if blocked {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the like and its notification are saved together
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error liking chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Insert like into database (liking twice is a no-op)
+	dbParams := database.LikeChirpParams{
+		UserID:  userID,
+		ChirpID: id,
+	}
+
+	rows, err := qtx.LikeChirp(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error liking chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Notify the chirp author the first time it is liked
+	var notifications []database.Notification
+	if rows > 0 {
+		notifications, err = createNotification(rq.Context(), qtx, dbChirp.UserID, userID, "like", uuid.NullUUID{UUID: id, Valid: true})
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error liking chirp")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error liking chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Push notifications to connected clients
+	cfg.notificationHub.Publish(rq.Context(), notifications)
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) unlikeChirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Delete like from database
+	dbParams := database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: id,
+	}
+
+	_, err = cfg.db.UnlikeChirp(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error unliking chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) getUserLikesHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Get liked chirps from database
+	dbParams := database.GetChirpsLikedByUserParams{
+		UserID:          id,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbRows, err := cfg.db.GetChirpsLikedByUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting likes")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database rows to likedChirp struct
+	liked := make([]likedChirp, len(dbRows))
+	for i, dbRow := range dbRows {
+		liked[i] = likedChirp{
+			Chirp:   chirpFromDB(dbRow.Chirp),
+			LikedAt: dbRow.LikedAt,
+		}
+	}
+
+	// Trim to the requested page and build cursors (ordered by like time)
+	liked, next, prev := paginate(liked, page, likedChirpCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	chirps := make([]Chirp, len(liked))
+	for i, like := range liked {
+		chirps[i] = like.Chirp
+	}
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, cfg.getOptionalUserID(rq))
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting likes")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return chirps
+	err = respondWithJSON(rw, http.StatusOK, ChirpPage{Chirps: chirps, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
@@ -1,22 +1,32 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
 	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/storage"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"sync/atomic"
+	"time"
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	db             *database.Queries
-	platform       string
-	tokenSecret    string
-	polkaKey       string
+	fileserverHits  atomic.Int32
+	db              *database.Queries
+	dbConn          *sql.DB
+	platform        string
+	keyring         *auth.Keyring
+	denylist        accessTokenDenylist
+	polkaKey        string
+	broker          *chirpBroker
+	notificationHub *notificationHub
+	mediaStore      storage.Store
 }
 
 func main() {
@@ -41,28 +51,169 @@ func main() {
 	mux := http.NewServeMux()
 
 	// Create a new apiConfig instance
+	queries := database.New(db)
 	cfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             database.New(db),
-		platform:       os.Getenv("PLATFORM"),
-		tokenSecret:    os.Getenv("TOKEN_SECRET"),
-		polkaKey:       os.Getenv("POLKA_KEY"),
+		fileserverHits:  atomic.Int32{},
+		db:              queries,
+		dbConn:          db,
+		platform:        os.Getenv("PLATFORM"),
+		polkaKey:        os.Getenv("POLKA_KEY"),
+		broker:          newChirpBroker(chirpEventHistorySize),
+		notificationHub: newNotificationHub(),
+		denylist:        queries,
 	}
 
+	// Sign access tokens with the keys in JWT_KEYS_FILE or a single TOKEN_SECRET key
+	if keysFile := os.Getenv("JWT_KEYS_FILE"); len(keysFile) > 0 {
+		cfg.keyring, err = auth.LoadKeyring(keysFile, os.Getenv("JWT_ACTIVE_KID"))
+	} else {
+		cfg.keyring, err = auth.NewHMACKeyring("default", os.Getenv("TOKEN_SECRET"))
+	}
+	if err != nil {
+		log.Fatalf("Error loading signing keys: %v", err)
+	}
+
+	// Configure access token validation (the issuer defaults to "chirpy")
+	validationOptions := auth.ValidationOptions{
+		Issuer:   os.Getenv("JWT_ISSUER"),
+		Audience: os.Getenv("JWT_AUDIENCE"),
+	}
+	if algorithms := os.Getenv("JWT_ALGORITHMS"); len(algorithms) > 0 {
+		validationOptions.Algorithms = strings.Split(algorithms, ",")
+	}
+	if leeway := os.Getenv("JWT_LEEWAY"); len(leeway) > 0 {
+		validationOptions.Leeway, err = time.ParseDuration(leeway)
+		if err != nil {
+			log.Fatalf("Error parsing JWT_LEEWAY: %v", err)
+		}
+	}
+
+	// Accept kid-less tokens from before signing keys had IDs until they expire
+	if cutoff := os.Getenv("JWT_LEGACY_ISSUED_BEFORE"); len(cutoff) > 0 {
+		validationOptions.LegacyIssuedBefore, err = time.Parse(time.RFC3339, cutoff)
+		if err != nil {
+			log.Fatalf("Error parsing JWT_LEGACY_ISSUED_BEFORE: %v", err)
+		}
+	}
+
+	err = cfg.keyring.SetValidationOptions(validationOptions)
+	if err != nil {
+		log.Fatalf("Error setting token validation options: %v", err)
+	}
+
+	// Share chirp and notification events between server instances through Postgres
+	if os.Getenv("EVENT_BACKEND") == "postgres" {
+		err = listenForEvents(dbURL, cfg.db, cfg.broker, cfg.notificationHub)
+		if err != nil {
+			log.Fatalf("Error listening for events: %v", err)
+		}
+	}
+
+	// Store uploaded media in an S3-compatible bucket or on the local filesystem
+	if os.Getenv("MEDIA_BACKEND") == "s3" {
+		cfg.mediaStore = &storage.S3Store{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Region:          os.Getenv("S3_REGION"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		}
+	} else {
+		mediaDir := os.Getenv("MEDIA_DIR")
+		if len(mediaDir) == 0 {
+			mediaDir = "media"
+		}
+
+		cfg.mediaStore, err = storage.NewLocalStore(mediaDir, "/media/")
+		if err != nil {
+			log.Fatalf("Error creating media directory: %v", err)
+		}
+		mux.Handle("GET /media/", http.StripPrefix("/media/", http.FileServer(mediaFileSystem{http.Dir(mediaDir)})))
+	}
+
+	// Close polls once their closing time has passed
+	go cfg.closePolls(context.Background(), pollCloseInterval)
+
+	// Delete uploads that were never attached to a chirp
+	go cfg.pruneUnattachedMedia(context.Background(), mediaPruneInterval)
+
+	// Publish scheduled chirps once their publish time has passed
+	go cfg.publishScheduledChirps(context.Background(), schedulerInterval)
+
+	// Forget revoked access tokens once they have expired
+	go cfg.pruneRevokedAccessTokens(context.Background(), denylistPruneInterval)
+
 	// Register handler functions
 	mux.Handle("/app/", http.StripPrefix("/app/", cfg.middlewareMetricsInc(http.FileServer(http.Dir(".")))))
 	mux.HandleFunc("GET /api/healthz", healthCheckHandler)
+	mux.HandleFunc("GET /.well-known/jwks.json", cfg.jwksHandler)
 	mux.HandleFunc("GET /admin/metrics", cfg.hitsHandler)
 	mux.HandleFunc("POST /admin/reset", cfg.resetHandler)
 	mux.HandleFunc("POST /api/users", cfg.createUserHandler)
 	mux.HandleFunc("POST /api/chirps", cfg.createChirpHandler)
 	mux.HandleFunc("GET /api/chirps", cfg.getChirpsHandler)
 	mux.HandleFunc("GET /api/chirps/{id}", cfg.getChirpHandler)
+	mux.HandleFunc("GET /api/stream/chirps", cfg.streamChirpsHandler)
+	mux.HandleFunc("GET /api/ws", cfg.wsHandler)
+	mux.HandleFunc("PUT /api/chirps/{id}", cfg.updateChirpHandler)
 	mux.HandleFunc("DELETE /api/chirps/{id}", cfg.deleteChirpHandler)
+	mux.HandleFunc("GET /api/chirps/{id}/revisions", cfg.getChirpRevisionsHandler)
+	mux.HandleFunc("GET /api/chirps/{id}/replies", cfg.getChirpRepliesHandler)
+	mux.HandleFunc("GET /api/chirps/{id}/thread", cfg.getChirpThreadHandler)
+	mux.HandleFunc("POST /api/chirps/{id}/like", cfg.likeChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{id}/like", cfg.unlikeChirpHandler)
+	mux.HandleFunc("POST /api/chirps/{id}/rechirp", cfg.rechirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{id}/rechirp", cfg.undoRechirpHandler)
+	mux.HandleFunc("POST /api/chirps/{id}/poll/vote", cfg.votePollHandler)
+	mux.HandleFunc("POST /api/chirps/{id}/bookmark", cfg.bookmarkChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{id}/bookmark", cfg.unbookmarkChirpHandler)
+	mux.HandleFunc("POST /api/chirps/{id}/pin", cfg.pinChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{id}/pin", cfg.unpinChirpHandler)
+	mux.HandleFunc("GET /api/bookmarks", cfg.getBookmarksHandler)
+	mux.HandleFunc("POST /api/bookmarks/collections", cfg.createBookmarkCollectionHandler)
+	mux.HandleFunc("GET /api/bookmarks/collections", cfg.getBookmarkCollectionsHandler)
+	mux.HandleFunc("DELETE /api/bookmarks/collections/{id}", cfg.deleteBookmarkCollectionHandler)
 	mux.HandleFunc("POST /api/login", cfg.loginHandler)
 	mux.HandleFunc("POST /api/refresh", cfg.tokenRefreshHandler)
 	mux.HandleFunc("POST /api/revoke", cfg.tokenRevokeHandler)
+	mux.HandleFunc("POST /api/logout", cfg.logoutHandler)
+	mux.HandleFunc("GET /api/sessions", cfg.getSessionsHandler)
+	mux.HandleFunc("DELETE /api/sessions/{id}", cfg.revokeSessionHandler)
+	mux.HandleFunc("POST /api/sessions/revoke_all", cfg.revokeAllSessionsHandler)
 	mux.HandleFunc("PUT /api/users", cfg.updateUserHandler)
+	mux.HandleFunc("PATCH /api/users/me", cfg.updateProfileHandler)
+	mux.HandleFunc("GET /api/users/{id}", cfg.getProfileHandler)
+	mux.HandleFunc("POST /api/users/{id}/follow", cfg.followUserHandler)
+	mux.HandleFunc("DELETE /api/users/{id}/follow", cfg.unfollowUserHandler)
+	mux.HandleFunc("GET /api/users/{id}/followers", cfg.getFollowersHandler)
+	mux.HandleFunc("GET /api/users/{id}/following", cfg.getFollowingHandler)
+	mux.HandleFunc("POST /api/users/{id}/block", cfg.blockUserHandler)
+	mux.HandleFunc("DELETE /api/users/{id}/block", cfg.unblockUserHandler)
+	mux.HandleFunc("POST /api/users/{id}/mute", cfg.muteUserHandler)
+	mux.HandleFunc("DELETE /api/users/{id}/mute", cfg.unmuteUserHandler)
+	mux.HandleFunc("GET /api/users/{id}/likes", cfg.getUserLikesHandler)
+	mux.HandleFunc("GET /api/users/{handle}/mentions", cfg.getUserMentionsHandler)
+	mux.HandleFunc("GET /api/timeline", cfg.getTimelineHandler)
+	mux.HandleFunc("GET /api/search/chirps", cfg.searchChirpsHandler)
+	mux.HandleFunc("GET /api/hashtags/trending", cfg.getTrendingHashtagsHandler)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.getHashtagChirpsHandler)
+	mux.HandleFunc("GET /api/notifications", cfg.getNotificationsHandler)
+	mux.HandleFunc("POST /api/notifications/read", cfg.markNotificationsReadHandler)
+	mux.HandleFunc("GET /api/notifications/unread_count", cfg.getUnreadNotificationCountHandler)
+	mux.HandleFunc("POST /api/conversations", cfg.createConversationHandler)
+	mux.HandleFunc("GET /api/conversations", cfg.getConversationsHandler)
+	mux.HandleFunc("GET /api/conversations/{id}", cfg.getConversationHandler)
+	mux.HandleFunc("POST /api/conversations/{id}/messages", cfg.createMessageHandler)
+	mux.HandleFunc("GET /api/conversations/{id}/messages", cfg.getMessagesHandler)
+	mux.HandleFunc("POST /api/conversations/{id}/read", cfg.markConversationReadHandler)
+	mux.HandleFunc("POST /api/drafts", cfg.createDraftHandler)
+	mux.HandleFunc("GET /api/drafts", cfg.getDraftsHandler)
+	mux.HandleFunc("GET /api/drafts/{id}", cfg.getDraftHandler)
+	mux.HandleFunc("PUT /api/drafts/{id}", cfg.updateDraftHandler)
+	mux.HandleFunc("DELETE /api/drafts/{id}", cfg.deleteDraftHandler)
+	mux.HandleFunc("POST /api/drafts/{id}/publish", cfg.publishDraftHandler)
+	mux.HandleFunc("POST /api/media", cfg.uploadMediaHandler)
 	mux.HandleFunc("POST /api/polka/webhooks", cfg.polkaWebhookHandler)
 
 	// Create new server instance
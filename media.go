@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	maxMediaSize           = 5 << 20
+	maxChirpyRedMediaSize  = 20 << 20
+	maxMediaPixels         = 40_000_000
+	maxChirpMedia          = 4
+	thumbnailSize          = 400
+	thumbnailQuality       = 80
+	multipartOverheadBytes = 64 << 10
+
+	// Uploads never attached to a chirp are deleted after a day
+	unattachedMediaTTL = 24 * time.Hour
+	mediaPruneInterval = time.Hour
+)
+
+// Uploads are sniffed rather than trusting the client's content type.
+var mediaExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+// Map database media to Media struct
+func (cfg *apiConfig) mediaFromDB(dbMedia database.Medium) Media {
+	return Media{
+		ID:           dbMedia.ID,
+		CreatedAt:    dbMedia.CreatedAt,
+		ContentType:  dbMedia.ContentType,
+		Size:         dbMedia.SizeBytes,
+		Width:        dbMedia.Width,
+		Height:       dbMedia.Height,
+		URL:          cfg.mediaStore.URL(dbMedia.StorageKey),
+		ThumbnailURL: cfg.mediaStore.URL(dbMedia.ThumbnailKey),
+	}
+}
+
+// makeThumbnail scales src down to fit within size x size, averaging the
+// pixels each thumbnail pixel covers. Transparent areas become white since
+// thumbnails are saved as JPEG.
+//
+// The source is converted to RGBA one band of rows at a time, which lets
+// draw.Draw use its fast paths for the decoded image types (RGBA, NRGBA,
+// YCbCr, ...) without holding a full size copy in memory.
+func makeThumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	thumbWidth, thumbHeight := width, height
+	if width > size || height > size {
+		if width >= height {
+			thumbWidth, thumbHeight = size, max(1, height*size/width)
+		} else {
+			thumbWidth, thumbHeight = max(1, width*size/height), size
+		}
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	band := image.NewRGBA(image.Rect(0, 0, width, (height+thumbHeight-1)/thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		y0 := y * height / thumbHeight
+		y1 := max(y0+1, (y+1)*height/thumbHeight)
+		draw.Draw(band, image.Rect(0, 0, width, y1-y0), src, image.Pt(bounds.Min.X, bounds.Min.Y+y0), draw.Src)
+
+		for x := 0; x < thumbWidth; x++ {
+			x0 := x * width / thumbWidth
+			x1 := max(x0+1, (x+1)*width/thumbWidth)
+
+			var r, g, b, a, n uint64
+			for sy := 0; sy < y1-y0; sy++ {
+				row := band.Pix[sy*band.Stride+x0*4 : sy*band.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r, g, b, a = r+uint64(row[i]), g+uint64(row[i+1]), b+uint64(row[i+2]), a+uint64(row[i+3])
+					n++
+				}
+			}
+			scaled.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+
+	thumb := image.NewRGBA(scaled.Bounds())
+	draw.Draw(thumb, thumb.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(thumb, thumb.Bounds(), scaled, image.Point{}, draw.Over)
+	return thumb
+}
+
+// mediaFileSystem serves stored media files but refuses directories, so the
+// media directory can't be listed.
+type mediaFileSystem struct {
+	fs http.FileSystem
+}
+
+func (m mediaFileSystem) Open(name string) (http.File, error) {
+	file, err := m.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fs.ErrNotExist
+	}
+
+	return file, nil
+}
+
+// deleteMediaFiles removes the stored files of media already deleted from the
+// database. A failure only leaves an unreachable file behind, so it is logged
+// rather than returned.
+func (cfg *apiConfig) deleteMediaFiles(ctx context.Context, dbMedia []database.Medium) {
+	for _, dbMedium := range dbMedia {
+		for _, key := range []string{dbMedium.StorageKey, dbMedium.ThumbnailKey} {
+			err := cfg.mediaStore.Delete(ctx, key)
+			if err != nil {
+				log.Printf("Error deleting media file %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// pruneUnattachedMedia deletes uploads that were never attached to a chirp.
+func (cfg *apiConfig) pruneUnattachedMedia(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		dbMedia, err := cfg.db.DeleteUnattachedMedia(ctx, time.Now().UTC().Add(-unattachedMediaTTL))
+		if err != nil {
+			log.Printf("Error deleting unattached media: %v", err)
+			continue
+		}
+		cfg.deleteMediaFiles(ctx, dbMedia)
+	}
+}
+
+// hydrateChirpMedia attaches uploaded media to chirps. Deleted chirps keep
+// their attachments hidden along with their body.
+func (cfg *apiConfig) hydrateChirpMedia(ctx context.Context, chirps []Chirp) error {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		if !chirp.Deleted {
+			ids = append(ids, chirp.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	dbRows, err := cfg.db.GetChirpMedia(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	media := make(map[uuid.UUID][]Media, len(dbRows))
+	for _, dbRow := range dbRows {
+		media[dbRow.ChirpID] = append(media[dbRow.ChirpID], cfg.mediaFromDB(dbRow.Medium))
+	}
+
+	for i := range chirps {
+		if !chirps[i].Deleted {
+			chirps[i].Media = media[chirps[i].ID]
+		}
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) uploadMediaHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get user from database (Chirpy Red users can upload bigger files)
+	dbUser, err := cfg.db.GetUserByID(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	limit := int64(maxMediaSize)
+	if dbUser.IsChirpyRed {
+		limit = maxChirpyRedMediaSize
+	}
+	rq.Body = http.MaxBytesReader(rw, rq.Body, limit+multipartOverheadBytes)
+
+	// Read the file part of the multipart form
+	reader, err := rq.MultipartReader()
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Expected multipart/form-data")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	var data []byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err == nil && part.FormName() == "file" {
+			data, err = io.ReadAll(io.LimitReader(part, limit+1))
+		}
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) || int64(len(data)) > limit {
+			err = respondWithError(rw, http.StatusRequestEntityTooLarge, "File is too large")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		if err != nil {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid multipart form")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		if data != nil {
+			break
+		}
+	}
+
+	if len(data) == 0 {
+		err = respondWithError(rw, http.StatusBadRequest, "File is required")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if the file is not a supported image
+	contentType := http.DetectContentType(data)
+	extension, ok := mediaExtensions[contentType]
+	if !ok {
+		err = respondWithError(rw, http.StatusUnsupportedMediaType, "Unsupported media type")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check dimensions before decoding so huge images can't exhaust memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid image")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if int64(config.Width)*int64(config.Height) > maxMediaPixels {
+		err = respondWithError(rw, http.StatusBadRequest, "Image dimensions are too large")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid image")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Generate thumbnail
+	var thumbnail bytes.Buffer
+	err = jpeg.Encode(&thumbnail, makeThumbnail(img, thumbnailSize), &jpeg.Options{Quality: thumbnailQuality})
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating thumbnail")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Save the image and thumbnail to storage
+	id := uuid.New()
+	storageKey := id.String() + extension
+	thumbnailKey := id.String() + "_thumb.jpg"
+
+	err = cfg.mediaStore.Put(rq.Context(), storageKey, bytes.NewReader(data), int64(len(data)), contentType)
+	if err == nil {
+		err = cfg.mediaStore.Put(rq.Context(), thumbnailKey, &thumbnail, int64(thumbnail.Len()), "image/jpeg")
+	}
+	if err != nil {
+		log.Printf("Error storing media: %v", err)
+		cfg.deleteStoredMedia(storageKey, thumbnailKey)
+		err = respondWithError(rw, http.StatusInternalServerError, "Error uploading media")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Insert media into database
+	dbParams := database.CreateMediaParams{
+		ID:           id,
+		UserID:       userID,
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		Width:        int32(config.Width),
+		Height:       int32(config.Height),
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+	}
+
+	dbMedia, err := cfg.db.CreateMedia(rq.Context(), dbParams)
+	if err != nil {
+		cfg.deleteStoredMedia(storageKey, thumbnailKey)
+		err = respondWithError(rw, http.StatusInternalServerError, "Error uploading media")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return media
+	err = respondWithJSON(rw, http.StatusCreated, cfg.mediaFromDB(dbMedia))
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+// deleteStoredMedia cleans up files whose upload could not be completed.
+func (cfg *apiConfig) deleteStoredMedia(keys ...string) {
+	for _, key := range keys {
+		err := cfg.mediaStore.Delete(context.Background(), key)
+		if err != nil {
+			log.Printf("Error deleting media %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMakeThumbnail(t *testing.T) {
+	tests := []struct {
+		name           string
+		width, height  int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{"KeepsSmallImages", 100, 50, 100, 50},
+		{"ScalesLandscape", 1600, 900, 400, 225},
+		{"ScalesPortrait", 900, 1600, 225, 400},
+		{"ScalesSquare", 800, 800, 400, 400},
+		{"KeepsThinImagesVisible", 4000, 2, 400, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+			bounds := makeThumbnail(src, thumbnailSize).Bounds()
+			if bounds.Dx() != tt.expectedWidth || bounds.Dy() != tt.expectedHeight {
+				t.Errorf("Expected %dx%d but got %dx%d", tt.expectedWidth, tt.expectedHeight, bounds.Dx(), bounds.Dy())
+			}
+		})
+	}
+}
+
+func TestMakeThumbnailColors(t *testing.T) {
+	// Left half opaque red, right half transparent
+	src := image.NewNRGBA(image.Rect(0, 0, 800, 800))
+	for y := 0; y < 800; y++ {
+		for x := 0; x < 400; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	thumb := makeThumbnail(src, thumbnailSize)
+
+	tests := []struct {
+		name     string
+		x, y     int
+		expected color.RGBA
+	}{
+		{"KeepsOpaquePixels", 10, 10, color.RGBA{R: 255, A: 255}},
+		{"FillsTransparencyWithWhite", 390, 10, color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := color.RGBAModel.Convert(thumb.At(tt.x, tt.y)).(color.RGBA)
+			if result != tt.expected {
+				t.Errorf("Expected %v but got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestMakeThumbnailSourceTypes(t *testing.T) {
+	// Every source is solid gray, so every thumbnail pixel should be too
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	rect := image.Rect(0, 0, 900, 600)
+
+	rgba := image.NewRGBA(rect)
+	draw.Draw(rgba, rect, image.NewUniform(gray), image.Point{}, draw.Src)
+
+	ycbcr := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = 128
+	}
+	for i := range ycbcr.Cb {
+		ycbcr.Cb[i], ycbcr.Cr[i] = 128, 128
+	}
+
+	// Sub-images don't start at the origin
+	offset := image.NewRGBA(image.Rect(0, 0, 1000, 700))
+	draw.Draw(offset, offset.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	draw.Draw(offset, image.Rect(100, 100, 1000, 700), image.NewUniform(gray), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name     string
+		src      image.Image
+		expected color.RGBA
+	}{
+		{"RGBA", rgba, gray},
+		{"YCbCr", ycbcr, gray},
+		{"Paletted", image.NewPaletted(rect, color.Palette{gray}), gray},
+		{"SubImage", offset.SubImage(image.Rect(100, 100, 1000, 700)), gray},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb := makeThumbnail(tt.src, thumbnailSize)
+			bounds := thumb.Bounds()
+			if bounds.Dx() != 400 || bounds.Dy() != 266 {
+				t.Fatalf("Expected 400x266 but got %dx%d", bounds.Dx(), bounds.Dy())
+			}
+			for _, p := range []image.Point{{0, 0}, {399, 0}, {200, 133}, {399, 265}} {
+				result := color.RGBAModel.Convert(thumb.At(p.X, p.Y)).(color.RGBA)
+				if result != tt.expected {
+					t.Errorf("Expected %v at %v but got %v", tt.expected, p, result)
+				}
+			}
+		})
+	}
+}
+
+func TestMediaFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "thumbnails"), 0o755)
+	if err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "photo.png"), []byte("png"), 0o644)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	server := http.FileServer(mediaFileSystem{http.Dir(dir)})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"ServesFiles", "/photo.png", http.StatusOK},
+		{"RefusesRoot", "/", http.StatusNotFound},
+		{"RefusesDirectories", "/thumbnails/", http.StatusNotFound},
+		{"MissingFile", "/missing.png", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rw.Code != tt.expected {
+				t.Errorf("Expected %d but got %d", tt.expected, rw.Code)
+			}
+		})
+	}
+}
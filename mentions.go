@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Handles are 3 to 30 ASCII letters, digits or underscores.
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// A mention starts after whitespace or punctuation, so email addresses like
+// "me@example.com" don't count.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_@])@([A-Za-z0-9_]+)`)
+
+func validHandle(handle string) bool {
+	return handlePattern.MatchString(handle)
+}
+
+// extractMentions returns the distinct lowercased handles mentioned in body
+// in the order they first appear.
+func extractMentions(body string) []string {
+	matches := mentionPattern.FindAllStringSubmatch(body, -1)
+
+	handles := make([]string, 0, len(matches))
+	seen := make(map[string]bool, len(matches))
+	for _, match := range matches {
+		handle := strings.ToLower(match[1])
+		if !validHandle(handle) || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+
+	return handles
+}
+
+// saveChirpMentions replaces the mentions recorded for chirp with the ones in
+// its current body and notifies users who weren't mentioned before, returning
+// the notifications to publish once the transaction commits. Authors
+// mentioning themselves are ignored.
+func saveChirpMentions(ctx context.Context, qtx *database.Queries, chirp database.Chirp) ([]database.Notification, error) {
+	previousIDs, err := qtx.GetChirpMentionUserIDs(ctx, chirp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = qtx.DeleteChirpMentions(ctx, chirp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	handles := extractMentions(chirp.Body)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	// Users who blocked the author can't be mentioned by them
+	mentionableParams := database.GetMentionableUserIDsParams{
+		Handles:  handles,
+		AuthorID: chirp.UserID,
+	}
+
+	userIDs, err := qtx.GetMentionableUserIDs(ctx, mentionableParams)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[uuid.UUID]bool, len(previousIDs))
+	for _, id := range previousIDs {
+		previous[id] = true
+	}
+
+	mentionedIDs := make([]uuid.UUID, 0, len(userIDs))
+	newIDs := make([]uuid.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id == chirp.UserID {
+			continue
+		}
+		mentionedIDs = append(mentionedIDs, id)
+		if !previous[id] {
+			newIDs = append(newIDs, id)
+		}
+	}
+
+	if len(mentionedIDs) == 0 {
+		return nil, nil
+	}
+
+	mentionParams := database.CreateChirpMentionsParams{
+		ChirpID: chirp.ID,
+		UserIds: mentionedIDs,
+	}
+
+	err = qtx.CreateChirpMentions(ctx, mentionParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return createNotifications(ctx, qtx, newIDs, chirp.UserID, "mention", uuid.NullUUID{UUID: chirp.ID, Valid: true})
+}
+
+func (cfg *apiConfig) getUserMentionsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get handle from URL (with or without the leading @)
+	handle := strings.TrimPrefix(rq.PathValue("handle"), "@")
+	if !validHandle(handle) {
+		err := respondWithError(rw, http.StatusBadRequest, "Invalid handle")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Get user from database
+	dbUser, err := cfg.db.GetUserByHandle(rq.Context(), handle)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "User not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get chirps mentioning user from database
+	dbParams := database.GetChirpsMentioningUserParams{
+		UserID:          dbUser.ID,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbRows, err := cfg.db.GetChirpsMentioningUser(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirps")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database chirps to Chirp struct
+	chirps := make([]Chirp, len(dbRows))
+	for i, dbRow := range dbRows {
+		chirps[i] = chirpFromDB(dbRow.Chirp)
+	}
+
+	// Trim to the requested page and build cursors
+	chirps, next, prev := paginate(chirps, page, chirpCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, cfg.getOptionalUserID(rq))
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirps")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return chirps
+	err = respondWithJSON(rw, http.StatusOK, ChirpPage{Chirps: chirps, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
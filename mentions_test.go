@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"FindsMentions", "Hey @alice and @bob_99", []string{"alice", "bob_99"}},
+		{"LowercasesAndDedupes", "@Alice @alice @ALICE", []string{"alice"}},
+		{"StopsAtPunctuation", "Thanks (@alice), see you @bob!", []string{"alice", "bob"}},
+		{"IgnoresEmailAddresses", "mail me at alice@example.com", []string{}},
+		{"IgnoresDoubleAt", "@@alice", []string{}},
+		{"IgnoresTooShort", "hi @al", []string{}},
+		{"IgnoresTooLong", "hi @abcdefghijklmnopqrstuvwxyz12345", []string{}},
+		{"IgnoresBareAt", "@ alone", []string{}},
+		{"HandlesEmptyString", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractMentions(tt.input)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v but got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Kind      string     `json:"kind"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id,omitempty"`
+	Read      bool       `json:"read"`
+}
+
+type markNotificationsReadParams struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+type UnreadNotificationCount struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+	PrevCursor    string         `json:"prev_cursor,omitempty"`
+}
+
+// Map database notification to Notification struct
+func notificationFromDB(dbNotification database.Notification) Notification {
+	notification := Notification{
+		ID:        dbNotification.ID,
+		CreatedAt: dbNotification.CreatedAt,
+		Kind:      dbNotification.Kind,
+		ActorID:   dbNotification.ActorID,
+		Read:      dbNotification.ReadAt.Valid,
+	}
+
+	if dbNotification.ChirpID.Valid {
+		notification.ChirpID = &dbNotification.ChirpID.UUID
+	}
+
+	return notification
+}
+
+// createNotification tells recipientID that actorID liked, replied to,
+// followed, mentioned, rechirped or quoted them. It must run in the same
+// transaction as the action itself, and the returned notifications should be
+// published once that commits. Acting on yourself doesn't notify you.
+func createNotification(ctx context.Context, qtx *database.Queries, recipientID, actorID uuid.UUID, kind string, chirpID uuid.NullUUID) ([]database.Notification, error) {
+	if recipientID == actorID {
+		return nil, nil
+	}
+
+	dbParams := database.CreateNotificationParams{
+		UserID:  recipientID,
+		ActorID: actorID,
+		Kind:    kind,
+		ChirpID: chirpID,
+	}
+
+	dbNotification, err := qtx.CreateNotification(ctx, dbParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return []database.Notification{dbNotification}, nil
+}
+
+// createNotifications is createNotification for an action that reaches
+// several recipients at once, like mentioning more than one user.
+func createNotifications(ctx context.Context, qtx *database.Queries, recipientIDs []uuid.UUID, actorID uuid.UUID, kind string, chirpID uuid.NullUUID) ([]database.Notification, error) {
+	userIDs := make([]uuid.UUID, 0, len(recipientIDs))
+	for _, id := range recipientIDs {
+		if id != actorID {
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	dbParams := database.CreateNotificationsParams{
+		UserIds: userIDs,
+		ActorID: actorID,
+		Kind:    kind,
+		ChirpID: chirpID,
+	}
+
+	return qtx.CreateNotifications(ctx, dbParams)
+}
+
+func notificationCursor(notification Notification) pageCursor {
+	return pageCursor{CreatedAt: notification.CreatedAt, ID: notification.ID}
+}
+
+func (cfg *apiConfig) getNotificationsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters (newest first by default)
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if len(rq.URL.Query().Get("sort")) == 0 {
+		page.Sort = "desc"
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Get notifications from database
+	dbParams := database.GetNotificationsParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbNotifications, err := cfg.db.GetNotifications(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting notifications")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database notifications to Notification struct
+	notifications := make([]Notification, len(dbNotifications))
+	for i, dbNotification := range dbNotifications {
+		notifications[i] = notificationFromDB(dbNotification)
+	}
+
+	// Trim to the requested page and build cursors
+	notifications, next, prev := paginate(notifications, page, notificationCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Return notifications
+	err = respondWithJSON(rw, http.StatusOK, NotificationPage{Notifications: notifications, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) markNotificationsReadHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Decode request body (an empty body marks everything as read)
+	decoder := json.NewDecoder(rq.Body)
+	params := markNotificationsReadParams{}
+	err := decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Mark the given notifications, or all of them, as read
+	if len(params.IDs) > 0 {
+		dbParams := database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    params.IDs,
+		}
+		_, err = cfg.db.MarkNotificationsRead(rq.Context(), dbParams)
+	} else {
+		_, err = cfg.db.MarkAllNotificationsRead(rq.Context(), userID)
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating notifications")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) getUnreadNotificationCountHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Count unread notifications in database
+	count, err := cfg.db.GetUnreadNotificationCount(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting notifications")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return unread count
+	err = respondWithJSON(rw, http.StatusOK, UnreadNotificationCount{UnreadCount: count})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageCursor marks a position in a list ordered by (created_at, id), or by
+// (rank, created_at, id) for search results. Prev cursors point at the first
+// item of a page and fetch the items before it.
+type pageCursor struct {
+	Rank      float32
+	CreatedAt time.Time
+	ID        uuid.UUID
+	Prev      bool
+}
+
+type pageParams struct {
+	Sort   string
+	Limit  int32
+	Cursor *pageCursor
+}
+
+type ChirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	direction := "n"
+	if cursor.Prev {
+		direction = "p"
+	}
+
+	raw := direction + "|" + cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID.String() +
+		"|" + strconv.FormatFloat(float64(cursor.Rank), 'g', -1, 32)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(encoded string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+
+	// Cursor format is direction|created_at|id|rank (cursors issued before
+	// search results were ranked have no rank and decode as rank 0)
+	parts := strings.Split(string(raw), "|")
+	if len(parts) == 3 {
+		parts = append(parts, "0")
+	}
+	if len(parts) != 4 || (parts[0] != "n" && parts[0] != "p") {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+
+	rank, err := strconv.ParseFloat(parts[3], 32)
+	if err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+
+	return pageCursor{Rank: float32(rank), CreatedAt: createdAt, ID: id, Prev: parts[0] == "p"}, nil
+}
+
+func parsePageParams(query url.Values) (pageParams, error) {
+	params := pageParams{Sort: "asc", Limit: defaultPageLimit}
+
+	// Check for sort parameter
+	if sortParam := query.Get("sort"); len(sortParam) > 0 {
+		if sortParam != "asc" && sortParam != "desc" {
+			return pageParams{}, errors.New("invalid sort parameter")
+		}
+		params.Sort = sortParam
+	}
+
+	// Check for limit parameter
+	if limitParam := query.Get("limit"); len(limitParam) > 0 {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return pageParams{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		params.Limit = int32(limit)
+	}
+
+	// Check for cursor parameter
+	if cursorParam := query.Get("cursor"); len(cursorParam) > 0 {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			return pageParams{}, err
+		}
+		params.Cursor = &cursor
+	}
+
+	return params, nil
+}
+
+// queryOrder is the order rows must be fetched in. Pages before a prev
+// cursor are read backwards from the cursor and flipped afterwards.
+func (p pageParams) queryOrder() string {
+	if p.Cursor == nil || !p.Cursor.Prev {
+		return p.Sort
+	}
+	if p.Sort == "asc" {
+		return "desc"
+	}
+	return "asc"
+}
+
+// queryLimit fetches one extra row so we know whether another page exists.
+func (p pageParams) queryLimit() int32 {
+	return p.Limit + 1
+}
+
+func (p pageParams) cursorArgs() (sql.NullTime, uuid.NullUUID) {
+	if p.Cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}, uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// paginate trims rows fetched with queryLimit/queryOrder down to a page in
+// the requested sort order and returns the cursors either side of it.
+func paginate[T any](rows []T, p pageParams, key func(T) pageCursor) (page []T, next string, prev string) {
+	hasMore := len(rows) > int(p.Limit)
+	if hasMore {
+		rows = rows[:p.Limit]
+	}
+
+	backwards := p.Cursor != nil && p.Cursor.Prev
+	if backwards {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	// There is always a next page when paging backwards, and always a
+	// previous page when paging forwards from a cursor
+	if hasMore || backwards {
+		last := key(rows[len(rows)-1])
+		last.Prev = false
+		next = encodeCursor(last)
+	}
+	if (hasMore && backwards) || (p.Cursor != nil && !backwards) {
+		first := key(rows[0])
+		first.Prev = true
+		prev = encodeCursor(first)
+	}
+
+	return rows, next, prev
+}
+
+// isFirstPage reports whether the page paginate returned prev for is the
+// first one, including when it was reached by paging backwards.
+func isFirstPage(p pageParams, prev string) bool {
+	return p.Cursor == nil || (p.Cursor.Prev && len(prev) == 0)
+}
+
+func chirpCursor(chirp Chirp) pageCursor {
+	return pageCursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID}
+}
+
+// setPageLinks sets an RFC 8288 Link header pointing at the adjacent pages.
+func setPageLinks(rw http.ResponseWriter, rq *http.Request, next, prev string) {
+	links := make([]string, 0, 2)
+	for _, link := range []struct{ rel, cursor string }{{"next", next}, {"prev", prev}} {
+		if len(link.cursor) == 0 {
+			continue
+		}
+
+		query := rq.URL.Query()
+		query.Set("cursor", link.cursor)
+		u := url.URL{Path: rq.URL.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), link.rel))
+	}
+
+	if len(links) > 0 {
+		rw.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
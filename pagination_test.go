@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/base64"
+	"github.com/google/uuid"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	valid := pageCursor{CreatedAt: time.Date(2024, 10, 1, 12, 30, 0, 123456000, time.UTC), ID: uuid.New(), Prev: true}
+	ranked := pageCursor{Rank: 0.0607927, CreatedAt: time.Date(2024, 10, 1, 12, 30, 0, 0, time.UTC), ID: uuid.New()}
+	// Cursors issued before ranks were added have three parts
+	legacy := base64.RawURLEncoding.EncodeToString([]byte("p|" + valid.CreatedAt.Format(time.RFC3339Nano) + "|" + valid.ID.String()))
+
+	tests := []struct {
+		name    string
+		input   string
+		want    pageCursor
+		wantErr bool
+	}{
+		{"RoundTrip", encodeCursor(valid), valid, false},
+		{"RoundTripRank", encodeCursor(ranked), ranked, false},
+		{"LegacyWithoutRank", legacy, valid, false},
+		{"TooManyParts", base64.RawURLEncoding.EncodeToString([]byte("n|2024-10-01T12:30:00Z|" + valid.ID.String() + "|0|0")), pageCursor{}, true},
+		{"NotBase64", "not a cursor!", pageCursor{}, true},
+		{"WrongShape", encodeCursor(valid)[:10], pageCursor{}, true},
+		{"EmptyString", "", pageCursor{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeCursor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.CreatedAt.Equal(tt.want.CreatedAt) || got.ID != tt.want.ID || got.Prev != tt.want.Prev || got.Rank != tt.want.Rank {
+				t.Errorf("decodeCursor() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePageParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     url.Values
+		wantSort  string
+		wantLimit int32
+		wantErr   bool
+	}{
+		{"Defaults", url.Values{}, "asc", defaultPageLimit, false},
+		{"SortAndLimit", url.Values{"sort": {"desc"}, "limit": {"5"}}, "desc", 5, false},
+		{"InvalidSort", url.Values{"sort": {"sideways"}}, "", 0, true},
+		{"LimitTooLarge", url.Values{"limit": {"1000"}}, "", 0, true},
+		{"LimitZero", url.Values{"limit": {"0"}}, "", 0, true},
+		{"InvalidCursor", url.Values{"cursor": {"bogus"}}, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePageParams(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePageParams() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.Sort != tt.wantSort || got.Limit != tt.wantLimit {
+				t.Errorf("parsePageParams() got = %v/%d, want %v/%d", got.Sort, got.Limit, tt.wantSort, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	base := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	chirps := make([]Chirp, 5)
+	for i := range chirps {
+		chirps[i] = Chirp{ID: uuid.New(), CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+	}
+	cursor := &pageCursor{CreatedAt: base, ID: uuid.New()}
+	prevCursor := &pageCursor{CreatedAt: base, ID: uuid.New(), Prev: true}
+
+	tests := []struct {
+		name      string
+		rows      []Chirp
+		params    pageParams
+		wantFirst uuid.UUID
+		wantLen   int
+		wantNext  bool
+		wantPrev  bool
+	}{
+		{"FirstPageWithMore", chirps[:3], pageParams{Limit: 2}, chirps[0].ID, 2, true, false},
+		{"FirstPageOnly", chirps[:2], pageParams{Limit: 2}, chirps[0].ID, 2, false, false},
+		{"MiddlePage", chirps[:3], pageParams{Limit: 2, Cursor: cursor}, chirps[0].ID, 2, true, true},
+		{"LastPage", chirps[:1], pageParams{Limit: 2, Cursor: cursor}, chirps[0].ID, 1, false, true},
+		{"BackwardsWithMore", []Chirp{chirps[4], chirps[3], chirps[2]}, pageParams{Limit: 2, Cursor: prevCursor}, chirps[3].ID, 2, true, true},
+		{"BackwardsToStart", []Chirp{chirps[1], chirps[0]}, pageParams{Limit: 2, Cursor: prevCursor}, chirps[0].ID, 2, true, false},
+		{"Empty", nil, pageParams{Limit: 2}, uuid.Nil, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := append([]Chirp(nil), tt.rows...)
+			page, next, prev := paginate(rows, tt.params, chirpCursor)
+			if len(page) != tt.wantLen {
+				t.Fatalf("paginate() len = %d, want %d", len(page), tt.wantLen)
+			}
+			if len(page) > 0 && page[0].ID != tt.wantFirst {
+				t.Errorf("paginate() first = %v, want %v", page[0].ID, tt.wantFirst)
+			}
+			if (len(next) > 0) != tt.wantNext {
+				t.Errorf("paginate() next = %q, wantNext %v", next, tt.wantNext)
+			}
+			if (len(prev) > 0) != tt.wantPrev {
+				t.Errorf("paginate() prev = %q, wantPrev %v", prev, tt.wantPrev)
+			}
+		})
+	}
+}
+
+func TestIsFirstPage(t *testing.T) {
+	cursor := &pageCursor{CreatedAt: time.Now(), ID: uuid.New()}
+	prevCursor := &pageCursor{CreatedAt: time.Now(), ID: uuid.New(), Prev: true}
+
+	tests := []struct {
+		name   string
+		params pageParams
+		prev   string
+		want   bool
+	}{
+		{"NoCursor", pageParams{Limit: 2}, "", true},
+		{"BackwardsToStart", pageParams{Limit: 2, Cursor: prevCursor}, "", true},
+		{"BackwardsWithMore", pageParams{Limit: 2, Cursor: prevCursor}, "prev", false},
+		{"ForwardsFromCursor", pageParams{Limit: 2, Cursor: cursor}, "prev", false},
+		{"ForwardsPastEnd", pageParams{Limit: 2, Cursor: cursor}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isFirstPage(tt.params, tt.prev)
+			if got != tt.want {
+				t.Errorf("isFirstPage() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"slices"
+)
+
+const (
+	maxPinnedChirps          = 1
+	maxChirpyRedPinnedChirps = 3
+)
+
+// pinLimit returns how many chirps a user may keep pinned to their profile.
+func pinLimit(isChirpyRed bool) int {
+	if isChirpyRed {
+		return maxChirpyRedPinnedChirps
+	}
+	return maxPinnedChirps
+}
+
+// getPinnedChirps returns the author's pinned chirps, most recently pinned
+// first, with the pinned flag set.
+func (cfg *apiConfig) getPinnedChirps(ctx context.Context, authorID, viewerID uuid.UUID) ([]Chirp, error) {
+	dbParams := database.GetPinnedChirpsByUserIdParams{
+		UserID:   authorID,
+		ViewerID: viewerID,
+	}
+
+	dbRows, err := cfg.db.GetPinnedChirpsByUserId(ctx, dbParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return pinnedChirpsFromDB(dbRows), nil
+}
+
+// Map pinned database chirps to Chirp structs with the pinned flag set
+func pinnedChirpsFromDB(dbRows []database.GetPinnedChirpsByUserIdRow) []Chirp {
+	pinned := make([]Chirp, len(dbRows))
+	for i, dbRow := range dbRows {
+		pinned[i] = chirpFromDB(dbRow.Chirp)
+		pinned[i].Pinned = true
+	}
+	return pinned
+}
+
+// pinFirstPage puts the author's pinned chirps ahead of the first page of
+// their chirps, whichever way it was reached. Other pages are returned as
+// they are (the query leaves pinned chirps out of every page), so getPinned
+// is only called for a first page.
+func pinFirstPage(chirps []Chirp, page pageParams, prev string, getPinned func() ([]Chirp, error)) ([]Chirp, error) {
+	if !isFirstPage(page, prev) {
+		return chirps, nil
+	}
+
+	pinned, err := getPinned()
+	if err != nil {
+		return nil, err
+	}
+	return append(pinned, chirps...), nil
+}
+
+func (cfg *apiConfig) pinChirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so concurrent pins can't go over the limit
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error pinning chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Get and lock user so their pins are counted one request at a time
+	// (Chirpy Red users can pin more chirps)
+	dbUser, err := qtx.LockUser(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get and lock chirp so it can't be deleted while it is pinned
+	dbChirp, err := qtx.GetChirpByIdForUpdate(rq.Context(), id)
+	if err != nil || dbChirp.DeletedAt.Valid {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if user is not chirp owner
+	if dbChirp.UserID != userID {
+		err = respondWithError(rw, http.StatusForbidden, "Forbidden")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	pinnedIDs, err := qtx.GetPinnedChirpIDs(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error pinning chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Pinning an already pinned chirp is a no-op
+	if slices.Contains(pinnedIDs, id) {
+		respondWithNoContent(rw)
+		return
+	}
+
+	// Return error if the user already pinned as many chirps as allowed
+	if len(pinnedIDs) >= pinLimit(dbUser.IsChirpyRed) {
+		err = respondWithError(rw, http.StatusConflict, "Pin limit reached")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Insert pin into database
+	dbParams := database.PinChirpParams{
+		UserID:  userID,
+		ChirpID: id,
+	}
+
+	err = qtx.PinChirp(rq.Context(), dbParams)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error pinning chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) unpinChirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Delete pin from database
+	dbParams := database.UnpinChirpParams{
+		UserID:  userID,
+		ChirpID: id,
+	}
+
+	_, err = cfg.db.UnpinChirp(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error unpinning chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		isChirpyRed bool
+		want        int
+	}{
+		{"RegularUser", false, 1},
+		{"ChirpyRedUser", true, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pinLimit(tt.isChirpyRed)
+			if got != tt.want {
+				t.Errorf("pinLimit() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinnedChirpsFromDB(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	dbRows := []database.GetPinnedChirpsByUserIdRow{
+		{Chirp: database.Chirp{ID: first, Kind: "chirp"}},
+		{Chirp: database.Chirp{ID: second, Kind: "chirp"}},
+	}
+
+	got := pinnedChirpsFromDB(dbRows)
+	if len(got) != 2 || got[0].ID != first || got[1].ID != second {
+		t.Fatalf("pinnedChirpsFromDB() got = %v, want %v then %v", got, first, second)
+	}
+	for _, chirp := range got {
+		if !chirp.Pinned {
+			t.Errorf("pinnedChirpsFromDB() got pinned = %v, want %v", chirp.Pinned, true)
+		}
+	}
+}
+
+func TestPinFirstPage(t *testing.T) {
+	base := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	chirps := make([]Chirp, 4)
+	for i := range chirps {
+		chirps[i] = Chirp{ID: uuid.New(), CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+	}
+	pinned := []Chirp{{ID: uuid.New(), CreatedAt: base.Add(-time.Hour), Pinned: true}}
+
+	ids := func(chirps ...Chirp) []uuid.UUID {
+		result := make([]uuid.UUID, len(chirps))
+		for i, chirp := range chirps {
+			result[i] = chirp.ID
+		}
+		return result
+	}
+	cursor := &pageCursor{CreatedAt: chirps[1].CreatedAt, ID: chirps[1].ID}
+	prevCursor := &pageCursor{CreatedAt: chirps[2].CreatedAt, ID: chirps[2].ID, Prev: true}
+
+	tests := []struct {
+		name       string
+		rows       []Chirp
+		params     pageParams
+		want       []uuid.UUID
+		wantPinned bool
+	}{
+		{"FirstPage", chirps[:3], pageParams{Sort: "asc", Limit: 2}, ids(pinned[0], chirps[0], chirps[1]), true},
+		{"FirstPageDesc", []Chirp{chirps[3], chirps[2], chirps[1]}, pageParams{Sort: "desc", Limit: 2}, ids(pinned[0], chirps[3], chirps[2]), true},
+		{"NextPage", chirps[2:], pageParams{Sort: "asc", Limit: 2, Cursor: cursor}, ids(chirps[2], chirps[3]), false},
+		{"BackToFirstPage", []Chirp{chirps[1], chirps[0]}, pageParams{Sort: "asc", Limit: 2, Cursor: prevCursor}, ids(pinned[0], chirps[0], chirps[1]), true},
+		{"PrevPageInMiddle", []Chirp{chirps[1], chirps[0]}, pageParams{Sort: "asc", Limit: 1, Cursor: prevCursor}, ids(chirps[1]), false},
+		{"NoChirps", nil, pageParams{Sort: "asc", Limit: 2}, ids(pinned[0]), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := append([]Chirp(nil), tt.rows...)
+			page, _, prev := paginate(rows, tt.params, chirpCursor)
+
+			called := false
+			got, err := pinFirstPage(page, tt.params, prev, func() ([]Chirp, error) {
+				called = true
+				return append([]Chirp(nil), pinned...), nil
+			})
+			if err != nil {
+				t.Fatalf("pinFirstPage() error = %v", err)
+			}
+			if !slices.Equal(ids(got...), tt.want) {
+				t.Errorf("pinFirstPage() got = %v, want %v", ids(got...), tt.want)
+			}
+			if called != tt.wantPinned {
+				t.Errorf("pinFirstPage() fetched pins = %v, want %v", called, tt.wantPinned)
+			}
+			for _, chirp := range got {
+				if chirp.Pinned != (chirp.ID == pinned[0].ID) {
+					t.Errorf("pinFirstPage() chirp %v pinned = %v", chirp.ID, chirp.Pinned)
+				}
+			}
+		})
+	}
+}
+
+func TestChirpsByUserIdExcludesPinned(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("sql", "queries", "chirps.sql"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	query := ""
+	for _, chunk := range strings.Split(string(data), "-- name: ") {
+		if strings.HasPrefix(chunk, "GetChirpsByUserId ") {
+			query = chunk
+		}
+	}
+
+	// Pinned chirps only show up at the top of the first page, never in the pages themselves
+	want := "FROM pinned_chirps\n      WHERE chirp_id = chirps.id"
+	if !strings.Contains(query, want) {
+		t.Errorf("GetChirpsByUserId got = %q, want it to leave out %q", query, want)
+	}
+}
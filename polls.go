@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+	pollCloseInterval   = 30 * time.Second
+)
+
+type createPollParams struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type votePollParams struct {
+	Option int32 `json:"option"`
+}
+
+type PollOption struct {
+	Label string `json:"label"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+// Poll results are only included once the viewer has voted or the poll has
+// closed, so earlier votes don't sway later ones.
+type Poll struct {
+	ClosesAt   time.Time    `json:"closes_at"`
+	Closed     bool         `json:"closed"`
+	Options    []PollOption `json:"options"`
+	TotalVotes *int64       `json:"total_votes,omitempty"`
+	MyVote     *int32       `json:"my_vote,omitempty"`
+}
+
+// validate returns a message describing what is wrong with the poll, or an
+// empty string if it is valid.
+func (params createPollParams) validate(now time.Time) string {
+	if len(params.Options) < minPollOptions || len(params.Options) > maxPollOptions {
+		return fmt.Sprintf("A poll must have %d to %d options", minPollOptions, maxPollOptions)
+	}
+
+	for _, option := range params.Options {
+		option = strings.TrimSpace(option)
+		if len(option) == 0 || utf8.RuneCountInString(option) > maxPollOptionLength {
+			return fmt.Sprintf("Poll options must be 1 to %d characters", maxPollOptionLength)
+		}
+	}
+
+	duration := params.ClosesAt.Sub(now)
+	if duration < minPollDuration || duration > maxPollDuration {
+		return "Poll must close between 5 minutes and 7 days from now"
+	}
+
+	return ""
+}
+
+// buildPoll combines a poll with its options, the live vote counts and the
+// viewer's vote. Closed polls use the counts recorded when they closed.
+func buildPoll(dbPoll database.Poll, dbOptions []database.PollOption, counts map[int32]int64, myVote *int32, now time.Time) Poll {
+	poll := Poll{
+		ClosesAt: dbPoll.ClosesAt,
+		Closed:   dbPoll.ClosedAt.Valid || !now.Before(dbPoll.ClosesAt),
+		Options:  make([]PollOption, len(dbOptions)),
+		MyVote:   myVote,
+	}
+
+	showResults := poll.Closed || myVote != nil
+	var total int64
+	for i, dbOption := range dbOptions {
+		poll.Options[i].Label = dbOption.Label
+		if !showResults {
+			continue
+		}
+
+		votes := counts[dbOption.Position]
+		if dbPoll.ClosedAt.Valid {
+			votes = dbOption.FinalVotes
+		}
+		poll.Options[i].Votes = &votes
+		total += votes
+	}
+
+	if showResults {
+		poll.TotalVotes = &total
+	}
+
+	return poll
+}
+
+// hydrateChirpPolls attaches polls to the chirps that have one. Deleted
+// chirps keep their poll hidden along with their body.
+func (cfg *apiConfig) hydrateChirpPolls(ctx context.Context, chirps []Chirp, viewerID uuid.UUID) error {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		if !chirp.Deleted {
+			ids = append(ids, chirp.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	dbPolls, err := cfg.db.GetPollsByChirpIds(ctx, ids)
+	if err != nil || len(dbPolls) == 0 {
+		return err
+	}
+
+	pollIDs := make([]uuid.UUID, len(dbPolls))
+	for i, dbPoll := range dbPolls {
+		pollIDs[i] = dbPoll.ChirpID
+	}
+
+	// Get options
+	dbOptions, err := cfg.db.GetPollOptions(ctx, pollIDs)
+	if err != nil {
+		return err
+	}
+
+	options := make(map[uuid.UUID][]database.PollOption, len(dbPolls))
+	for _, dbOption := range dbOptions {
+		options[dbOption.ChirpID] = append(options[dbOption.ChirpID], dbOption)
+	}
+
+	// Get live vote counts
+	dbCounts, err := cfg.db.GetPollVoteCounts(ctx, pollIDs)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[uuid.UUID]map[int32]int64, len(dbPolls))
+	for _, row := range dbCounts {
+		if counts[row.ChirpID] == nil {
+			counts[row.ChirpID] = make(map[int32]int64)
+		}
+		counts[row.ChirpID][row.Position] = row.VoteCount
+	}
+
+	// Get the viewer's votes
+	myVotes := make(map[uuid.UUID]int32)
+	if viewerID != uuid.Nil {
+		voteParams := database.GetPollVotesByUserParams{
+			UserID:   viewerID,
+			ChirpIds: pollIDs,
+		}
+
+		dbVotes, err := cfg.db.GetPollVotesByUser(ctx, voteParams)
+		if err != nil {
+			return err
+		}
+		for _, row := range dbVotes {
+			myVotes[row.ChirpID] = row.Position
+		}
+	}
+
+	now := time.Now().UTC()
+	polls := make(map[uuid.UUID]*Poll, len(dbPolls))
+	for _, dbPoll := range dbPolls {
+		var myVote *int32
+		if position, ok := myVotes[dbPoll.ChirpID]; ok {
+			myVote = &position
+		}
+
+		poll := buildPoll(dbPoll, options[dbPoll.ChirpID], counts[dbPoll.ChirpID], myVote, now)
+		polls[dbPoll.ChirpID] = &poll
+	}
+
+	for i := range chirps {
+		if !chirps[i].Deleted {
+			chirps[i].Poll = polls[chirps[i].ID]
+		}
+	}
+
+	return nil
+}
+
+// closePolls periodically closes polls past their closing time and records
+// their final results. It is safe to run on several server instances.
+func (cfg *apiConfig) closePolls(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := cfg.db.ClosePolls(ctx)
+		if err != nil {
+			log.Printf("Error closing polls: %v", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) votePollHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Decode request body
+	decoder := json.NewDecoder(rq.Body)
+	params := votePollParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure chirp exists
+	dbChirp, err := cfg.db.GetChirpById(rq.Context(), id)
+	if err != nil || dbChirp.DeletedAt.Valid {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get poll and its options from database
+	dbPoll, err := cfg.db.GetPoll(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "Poll not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	dbOptions, err := cfg.db.GetPollOptions(rq.Context(), []uuid.UUID{id})
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error voting")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if option does not exist
+	if params.Option < 0 || int(params.Option) >= len(dbOptions) {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid option")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Insert vote into database (only while the poll is open, and once per user)
+	voteParams := database.CreatePollVoteParams{
+		UserID:   userID,
+		Position: params.Option,
+		ChirpID:  id,
+	}
+
+	rows, err := cfg.db.CreatePollVote(rq.Context(), voteParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error voting")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if rows == 0 {
+		msg := "Already voted"
+		if dbPoll.ClosedAt.Valid || !time.Now().UTC().Before(dbPoll.ClosesAt) {
+			msg = "Poll is closed"
+		}
+		err = respondWithError(rw, http.StatusConflict, msg)
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database chirp to Chirp struct
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+
+	// Add counts and the now visible results
+	err = cfg.hydrateChirps(rq.Context(), chirps, userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting chirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return chirp
+	err = respondWithJSON(rw, http.StatusOK, chirps[0])
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreatePollParamsValidate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		params   createPollParams
+		expected string
+	}{
+		{"AcceptsValidPoll", createPollParams{[]string{"Yes", "No"}, now.Add(time.Hour)}, ""},
+		{"AcceptsFourOptions", createPollParams{[]string{"A", "B", "C", "D"}, now.Add(time.Hour)}, ""},
+		{"RejectsOneOption", createPollParams{[]string{"Yes"}, now.Add(time.Hour)}, "A poll must have 2 to 4 options"},
+		{"RejectsFiveOptions", createPollParams{[]string{"A", "B", "C", "D", "E"}, now.Add(time.Hour)}, "A poll must have 2 to 4 options"},
+		{"RejectsBlankOption", createPollParams{[]string{"Yes", "  "}, now.Add(time.Hour)}, "Poll options must be 1 to 25 characters"},
+		{"RejectsLongOption", createPollParams{[]string{"Yes", strings.Repeat("a", 26)}, now.Add(time.Hour)}, "Poll options must be 1 to 25 characters"},
+		{"RejectsPastClosingTime", createPollParams{[]string{"Yes", "No"}, now.Add(-time.Hour)}, "Poll must close between 5 minutes and 7 days from now"},
+		{"RejectsFarClosingTime", createPollParams{[]string{"Yes", "No"}, now.Add(8 * 24 * time.Hour)}, "Poll must close between 5 minutes and 7 days from now"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.params.validate(now)
+			if result != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestBuildPoll(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	options := []database.PollOption{
+		{Position: 0, Label: "Yes", FinalVotes: 7},
+		{Position: 1, Label: "No", FinalVotes: 3},
+	}
+	counts := map[int32]int64{0: 2, 1: 1}
+	vote := int32(1)
+
+	open := database.Poll{ClosesAt: now.Add(time.Hour)}
+	expired := database.Poll{ClosesAt: now.Add(-time.Minute)}
+	finalized := database.Poll{ClosesAt: now.Add(-time.Hour), ClosedAt: sql.NullTime{Time: now, Valid: true}}
+
+	tests := []struct {
+		name           string
+		poll           database.Poll
+		myVote         *int32
+		expectedClosed bool
+		expectedVotes  []int64
+	}{
+		{"HidesResultsBeforeVoting", open, nil, false, nil},
+		{"ShowsLiveResultsAfterVoting", open, &vote, false, []int64{2, 1}},
+		{"ShowsLiveResultsOncePastClosingTime", expired, nil, true, []int64{2, 1}},
+		{"ShowsFinalResultsOnceClosed", finalized, nil, true, []int64{7, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := buildPoll(tt.poll, options, counts, tt.myVote, now)
+			if result.Closed != tt.expectedClosed {
+				t.Errorf("Expected closed %v but got %v", tt.expectedClosed, result.Closed)
+			}
+
+			if tt.expectedVotes == nil {
+				if result.TotalVotes != nil {
+					t.Errorf("Expected no total but got %d", *result.TotalVotes)
+				}
+				for _, option := range result.Options {
+					if option.Votes != nil {
+						t.Errorf("Expected no votes for %q but got %d", option.Label, *option.Votes)
+					}
+				}
+				return
+			}
+
+			var total int64
+			for i, option := range result.Options {
+				if option.Votes == nil || *option.Votes != tt.expectedVotes[i] {
+					t.Errorf("Expected %d votes for %q but got %v", tt.expectedVotes[i], option.Label, option.Votes)
+				}
+				total += tt.expectedVotes[i]
+			}
+			if result.TotalVotes == nil || *result.TotalVotes != total {
+				t.Errorf("Expected total %d but got %v", total, result.TotalVotes)
+			}
+		})
+	}
+}
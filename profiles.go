@@ -0,0 +1,256 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+	maxURLLength         = 200
+)
+
+// Profile is the public view of a user. It must never include the email or
+// password hash.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         string    `json:"handle,omitempty"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Location       string    `json:"location"`
+	Website        string    `json:"website"`
+	AvatarURL      string    `json:"avatar_url"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	ChirpCount     int64     `json:"chirp_count"`
+}
+
+// updateProfileParams only changes the fields that are present, and an empty
+// string clears a field.
+type updateProfileParams struct {
+	Handle      *string `json:"handle"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	Location    *string `json:"location"`
+	Website     *string `json:"website"`
+	AvatarURL   *string `json:"avatar_url"`
+}
+
+// validProfileURL reports whether raw is an absolute http or https URL.
+func validProfileURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+// trim removes surrounding whitespace from every field present, so lengths
+// and URLs are validated as they will be saved.
+func (params *updateProfileParams) trim() {
+	fields := []*string{params.Handle, params.DisplayName, params.Bio, params.Location, params.Website, params.AvatarURL}
+	for _, field := range fields {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+}
+
+// validate returns a message describing the first invalid field, or an empty
+// string if every field present is valid.
+func (params updateProfileParams) validate() string {
+	if params.Handle != nil && !validHandle(*params.Handle) {
+		return "Handle must be 3 to 30 letters, digits or underscores"
+	}
+
+	lengths := []struct {
+		name  string
+		value *string
+		max   int
+	}{
+		{"Display name", params.DisplayName, maxDisplayNameLength},
+		{"Bio", params.Bio, maxBioLength},
+		{"Location", params.Location, maxLocationLength},
+		{"Website", params.Website, maxURLLength},
+		{"Avatar URL", params.AvatarURL, maxURLLength},
+	}
+	for _, field := range lengths {
+		if field.value != nil && utf8.RuneCountInString(*field.value) > field.max {
+			return fmt.Sprintf("%s must be at most %d characters", field.name, field.max)
+		}
+	}
+
+	if params.Website != nil && len(*params.Website) > 0 && !validProfileURL(*params.Website) {
+		return "Website must be an http or https URL"
+	}
+	if params.AvatarURL != nil && len(*params.AvatarURL) > 0 && !validProfileURL(*params.AvatarURL) {
+		return "Avatar URL must be an http or https URL"
+	}
+
+	return ""
+}
+
+// nullString maps a missing field to NULL so the column is left unchanged.
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+func (cfg *apiConfig) updateProfileHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Decode request body
+	decoder := json.NewDecoder(rq.Body)
+	params := updateProfileParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Invalid request payload")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if any field is not valid once trimmed
+	params.trim()
+	if msg := params.validate(); len(msg) > 0 {
+		err = respondWithError(rw, http.StatusBadRequest, msg)
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Update profile in database
+	dbParams := database.UpdateUserProfileParams{
+		Handle:      nullString(params.Handle),
+		DisplayName: nullString(params.DisplayName),
+		Bio:         nullString(params.Bio),
+		Location:    nullString(params.Location),
+		Website:     nullString(params.Website),
+		AvatarUrl:   nullString(params.AvatarURL),
+		ID:          userID,
+	}
+
+	dbUser, err := cfg.db.UpdateUserProfile(rq.Context(), dbParams)
+	if isUniqueViolation(err) {
+		err = respondWithError(rw, http.StatusConflict, "Handle already in use")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		err = respondWithError(rw, http.StatusNotFound, "User not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error updating profile")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return user
+	err = respondWithJSON(rw, http.StatusOK, userFromDB(dbUser))
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getProfileHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get user ID or handle (with or without the leading @) from URL
+	var dbUser database.User
+	var err error
+	if id, parseErr := uuid.Parse(rq.PathValue("id")); parseErr == nil {
+		dbUser, err = cfg.db.GetUserByID(rq.Context(), id)
+	} else {
+		handle := strings.TrimPrefix(rq.PathValue("id"), "@")
+		if !validHandle(handle) {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid user ID or handle")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		dbUser, err = cfg.db.GetUserByHandle(rq.Context(), handle)
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "User not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get follower, following and chirp counts
+	counts, err := cfg.db.GetUserProfileCounts(rq.Context(), dbUser.ID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting profile")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database user to Profile struct
+	profile := Profile{
+		ID:             dbUser.ID,
+		CreatedAt:      dbUser.CreatedAt,
+		Handle:         dbUser.Handle.String,
+		DisplayName:    dbUser.DisplayName,
+		Bio:            dbUser.Bio,
+		Location:       dbUser.Location,
+		Website:        dbUser.Website,
+		AvatarURL:      dbUser.AvatarUrl,
+		IsChirpyRed:    dbUser.IsChirpyRed,
+		FollowerCount:  counts.FollowerCount,
+		FollowingCount: counts.FollowingCount,
+		ChirpCount:     counts.ChirpCount,
+	}
+
+	// Return profile
+	err = respondWithJSON(rw, http.StatusOK, profile)
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
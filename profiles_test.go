@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdateProfileParamsValidate(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		params   updateProfileParams
+		expected string
+	}{
+		{"AcceptsEmptyUpdate", updateProfileParams{}, ""},
+		{"AcceptsValidFields", updateProfileParams{DisplayName: str("Alice"), Website: str("https://example.com")}, ""},
+		{"AcceptsClearingFields", updateProfileParams{Bio: str(""), Website: str(""), AvatarURL: str("")}, ""},
+		{"RejectsInvalidHandle", updateProfileParams{Handle: str("a!")}, "Handle must be 3 to 30 letters, digits or underscores"},
+		{"RejectsLongDisplayName", updateProfileParams{DisplayName: str(strings.Repeat("a", 51))}, "Display name must be at most 50 characters"},
+		{"CountsCharactersNotBytes", updateProfileParams{Bio: str(strings.Repeat("é", 160))}, ""},
+		{"RejectsLongBio", updateProfileParams{Bio: str(strings.Repeat("a", 161))}, "Bio must be at most 160 characters"},
+		{"RejectsNonHTTPWebsite", updateProfileParams{Website: str("javascript:alert(1)")}, "Website must be an http or https URL"},
+		{"RejectsRelativeAvatarURL", updateProfileParams{AvatarURL: str("/avatar.png")}, "Avatar URL must be an http or https URL"},
+		{"TrimsBeforeLengthCheck", updateProfileParams{DisplayName: str("  " + strings.Repeat("a", 50) + "  ")}, ""},
+		{"TrimsHandle", updateProfileParams{Handle: str(" alice ")}, ""},
+		{"TrimsWebsite", updateProfileParams{Website: str(" https://example.com ")}, ""},
+		{"RejectsPaddedNonHTTPWebsite", updateProfileParams{Website: str("   javascript:alert(1)")}, "Website must be an http or https URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.trim()
+			result := tt.params.validate()
+			if result != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+)
+
+func (cfg *apiConfig) rechirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get chirp from database
+	original, err := cfg.db.GetChirpById(rq.Context(), id)
+	if err != nil || original.DeletedAt.Valid {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Rechirping a rechirp points at the chirp it rechirped
+	if original.Kind == "rechirp" && original.OriginalID.Valid {
+		original, err = cfg.db.GetChirpById(rq.Context(), original.OriginalID.UUID)
+		if err != nil || original.DeletedAt.Valid {
+			err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+	originalID := uuid.NullUUID{UUID: original.ID, Valid: true}
+
+	// Start transaction so the rechirp and its notification are saved together
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Insert rechirp into database
+	dbParams := database.CreateChirpParams{
+		Body:       "",
+		UserID:     userID,
+		Kind:       "rechirp",
+		OriginalID: originalID,
+	}
+
+	dbChirp, err := qtx.CreateChirp(rq.Context(), dbParams)
+	if isUniqueViolation(err) {
+		err = respondWithError(rw, http.StatusConflict, "Chirp already rechirped")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Notify the original author
+	notifications, err := createNotification(rq.Context(), qtx, original.UserID, userID, "rechirp", originalID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Push notifications to connected clients
+	cfg.notificationHub.Publish(rq.Context(), notifications)
+
+	// Map database chirp to Chirp struct
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+
+	// Embed the original chirp
+	err = cfg.hydrateChirps(rq.Context(), chirps, userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error creating rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Push rechirp to stream subscribers
+	cfg.broker.Publish(rq.Context(), "created", chirps[0])
+
+	// Return rechirp
+	err = respondWithJSON(rw, http.StatusCreated, chirps[0])
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) undoRechirpHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so replies can't sneak in between the check and the delete
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Lock rechirp so no new replies are attached while deleting
+	dbParams := database.GetRechirpForUpdateParams{
+		UserID:     userID,
+		OriginalID: uuid.NullUUID{UUID: id, Valid: true},
+	}
+
+	dbChirp, err := qtx.GetRechirpForUpdate(rq.Context(), dbParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = respondWithError(rw, http.StatusNotFound, "Rechirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Tombstone rechirp if others replied to it, otherwise delete it (replies cascade)
+	deletedMedia, err := removeChirp(rq.Context(), qtx, dbChirp)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error deleting rechirp")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Remove media files of tombstones pruned along with it
+	cfg.deleteMediaFiles(rq.Context(), deletedMedia)
+
+	// Push deletion to stream subscribers
+	deleted := chirpFromDB(dbChirp)
+	deleted.Deleted = true
+	cfg.broker.Publish(rq.Context(), "deleted", deleted)
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
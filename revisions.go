@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"time"
+)
+
+type ChirpRevision struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	Body      string    `json:"body"`
+}
+
+func (cfg *apiConfig) getChirpRevisionsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Make sure chirp exists
+	dbChirp, err := cfg.db.GetChirpById(rq.Context(), id)
+	if err != nil || dbChirp.DeletedAt.Valid {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get chirp revisions from database
+	dbRevisions, err := cfg.db.GetChirpRevisions(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting revisions")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database revisions to ChirpRevision struct
+	revisions := make([]ChirpRevision, len(dbRevisions))
+	for i, dbRevision := range dbRevisions {
+		revisions[i] = ChirpRevision{
+			ID:        dbRevision.ID,
+			CreatedAt: dbRevision.CreatedAt,
+			ChirpID:   dbRevision.ChirpID,
+			Body:      dbRevision.Body,
+		}
+	}
+
+	// Return revisions
+	err = respondWithJSON(rw, http.StatusOK, revisions)
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"database/sql"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"time"
+)
+
+type rankedChirp struct {
+	Chirp Chirp
+	Rank  float32
+}
+
+func rankedChirpCursor(ranked rankedChirp) pageCursor {
+	return pageCursor{Rank: ranked.Rank, CreatedAt: ranked.Chirp.CreatedAt, ID: ranked.Chirp.ID}
+}
+
+func (cfg *apiConfig) searchChirpsHandler(rw http.ResponseWriter, rq *http.Request) {
+	query := rq.URL.Query()
+
+	// Return error if search query is missing or empty
+	searchQuery := query.Get("q")
+	if len(searchQuery) == 0 {
+		err := respondWithError(rw, http.StatusBadRequest, "Search query is required")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for order parameter
+	orderParam := query.Get("order")
+	if len(orderParam) == 0 {
+		orderParam = "relevance"
+	}
+
+	if orderParam != "relevance" && orderParam != "recent" {
+		err := respondWithError(rw, http.StatusBadRequest, "Invalid order parameter")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters (best matches first by default)
+	page, err := parsePageParams(query)
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	if len(query.Get("sort")) == 0 {
+		page.Sort = "desc"
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Check for author id query parameter
+	authorID := uuid.NullUUID{}
+	if authorParam := query.Get("author_id"); len(authorParam) > 0 {
+		authorID.UUID, err = uuid.Parse(authorParam)
+		if err != nil {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid author ID")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		authorID.Valid = true
+	}
+
+	// Check for date range query parameters
+	since := sql.NullTime{}
+	if sinceParam := query.Get("since"); len(sinceParam) > 0 {
+		since.Time, err = time.Parse(time.RFC3339, sinceParam)
+		if err != nil {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid since parameter")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		since.Time = since.Time.UTC()
+		since.Valid = true
+	}
+
+	until := sql.NullTime{}
+	if untilParam := query.Get("until"); len(untilParam) > 0 {
+		until.Time, err = time.Parse(time.RFC3339, untilParam)
+		if err != nil {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid until parameter")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		until.Time = until.Time.UTC()
+		until.Valid = true
+	}
+
+	// Signed in callers don't see chirps by users they blocked or muted
+	viewerID := cfg.getOptionalUserID(rq)
+
+	var chirps []Chirp
+	var next, prev string
+
+	if orderParam == "relevance" {
+		// Search chirps ordered by rank
+		dbParams := database.SearchChirpsByRankParams{
+			Query:           searchQuery,
+			AuthorID:        authorID,
+			Since:           since,
+			Until:           until,
+			ViewerID:        viewerID,
+			CursorCreatedAt: cursorCreatedAt,
+			Sort:            page.queryOrder(),
+			CursorID:        cursorID,
+			PageLimit:       page.queryLimit(),
+		}
+		if page.Cursor != nil {
+			dbParams.CursorRank = page.Cursor.Rank
+		}
+
+		dbRows, err := cfg.db.SearchChirpsByRank(rq.Context(), dbParams)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error searching chirps")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		// Map database rows to rankedChirp struct
+		ranked := make([]rankedChirp, len(dbRows))
+		for i, dbRow := range dbRows {
+			ranked[i] = rankedChirp{
+				Chirp: chirpFromDB(dbRow.Chirp),
+				Rank:  dbRow.Rank,
+			}
+		}
+
+		// Trim to the requested page and build cursors
+		ranked, next, prev = paginate(ranked, page, rankedChirpCursor)
+
+		chirps = make([]Chirp, len(ranked))
+		for i, match := range ranked {
+			chirps[i] = match.Chirp
+		}
+	} else {
+		// Search chirps ordered by creation time
+		dbParams := database.SearchChirpsByRecencyParams{
+			Query:           searchQuery,
+			AuthorID:        authorID,
+			Since:           since,
+			Until:           until,
+			ViewerID:        viewerID,
+			CursorCreatedAt: cursorCreatedAt,
+			Sort:            page.queryOrder(),
+			CursorID:        cursorID,
+			PageLimit:       page.queryLimit(),
+		}
+
+		dbChirps, err := cfg.db.SearchChirpsByRecency(rq.Context(), dbParams)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error searching chirps")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		// Map database chirps to Chirp struct
+		chirps = make([]Chirp, len(dbChirps))
+		for i, dbChirp := range dbChirps {
+			chirps[i] = chirpFromDB(dbChirp)
+		}
+
+		// Trim to the requested page and build cursors
+		chirps, next, prev = paginate(chirps, page, chirpCursor)
+	}
+	setPageLinks(rw, rq, next, prev)
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, viewerID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error searching chirps")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return chirps
+	err = respondWithJSON(rw, http.StatusOK, ChirpPage{Chirps: chirps, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxUserAgentLen = 512
+
+// Session is a signed in device. It covers every refresh token rotated from
+// the same login, so its ID is the token family ID.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+}
+
+// clientIP returns the address the request came from without its port.
+func clientIP(rq *http.Request) string {
+	host, _, err := net.SplitHostPort(rq.RemoteAddr)
+	if err != nil {
+		return rq.RemoteAddr
+	}
+	return host
+}
+
+// clientUserAgent returns the request's user agent, cut short so clients can't
+// fill the sessions table with arbitrarily long headers.
+func clientUserAgent(rq *http.Request) string {
+	userAgent := rq.UserAgent()
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLen], "")
+	}
+	return userAgent
+}
+
+func (cfg *apiConfig) getSessionsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get active sessions from database
+	dbSessions, err := cfg.db.GetSessions(rq.Context(), userID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting sessions")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database rows to Session struct
+	sessions := make([]Session, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		sessions[i] = Session{
+			ID:         dbSession.ID,
+			CreatedAt:  dbSession.CreatedAt,
+			LastUsedAt: dbSession.LastUsedAt,
+			ExpiresAt:  dbSession.ExpiresAt,
+			UserAgent:  dbSession.UserAgent,
+			IPAddress:  dbSession.IpAddress,
+		}
+	}
+
+	// Return sessions
+	err = respondWithJSON(rw, http.StatusOK, sessions)
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) revokeSessionHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get session ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid session ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	userID, err := cfg.validateJWT(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Revoke the session's refresh tokens (only the user's own sessions match)
+	dbParams := database.RevokeSessionParams{
+		UserID:   userID,
+		FamilyID: id,
+	}
+
+	rows, err := cfg.db.RevokeSession(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error revoking session")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if rows == 0 {
+		err = respondWithError(rw, http.StatusNotFound, "Session not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) revokeAllSessionsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	accessToken, err := cfg.validateAccessToken(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return error if the access token used for this request couldn't be revoked with the rest
+	if len(accessToken.ID) == 0 {
+		err = respondWithError(rw, http.StatusBadRequest, "Token can't be revoked, refresh it first")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Revoke every refresh token the user holds
+	err = cfg.db.RevokeAllSessions(rq.Context(), accessToken.UserID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error revoking sessions")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Revoke the access token used for this request too (in case it isn't tied to a session)
+	err = cfg.revokeAccessToken(rq.Context(), accessToken)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error revoking sessions")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return success (no content)
+	respondWithNoContent(rw)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{"IPv4WithPort", "203.0.113.7:52100", "203.0.113.7"},
+		{"IPv6WithPort", "[2001:db8::1]:443", "2001:db8::1"},
+		{"NoPort", "203.0.113.7", "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rq := &http.Request{RemoteAddr: tt.remoteAddr}
+			got := clientIP(rq)
+			if got != tt.want {
+				t.Errorf("clientIP() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{"RegularUserAgent", "Mozilla/5.0", "Mozilla/5.0"},
+		{"MissingUserAgent", "", ""},
+		{"LongUserAgentTruncated", strings.Repeat("a", maxUserAgentLen+10), strings.Repeat("a", maxUserAgentLen)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rq := &http.Request{Header: http.Header{}}
+			if len(tt.userAgent) > 0 {
+				rq.Header.Set("User-Agent", tt.userAgent)
+			}
+			got := clientUserAgent(rq)
+			if got != tt.want {
+				t.Errorf("clientUserAgent() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+-- name: BlockUser :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2;
+
+-- name: IsBlocked :one
+SELECT EXISTS (
+    SELECT 1
+    FROM blocks
+    WHERE blocker_id = $1
+      AND blocked_id = $2
+) AS is_blocked;
+
+-- name: HasBlocksBetween :one
+SELECT EXISTS (
+    SELECT 1
+    FROM blocks
+    WHERE blocker_id = ANY(sqlc.arg(user_ids)::uuid[])
+      AND blocked_id = ANY(sqlc.arg(user_ids)::uuid[])
+) AS has_blocks;
+
+-- name: IsBlockedInConversation :one
+SELECT EXISTS (
+    SELECT 1
+    FROM conversation_participants
+    JOIN blocks ON blocks.blocker_id = conversation_participants.user_id
+    WHERE conversation_participants.conversation_id = sqlc.arg(conversation_id)
+      AND blocks.blocked_id = sqlc.arg(blocked_id)
+) AS is_blocked;
+
+-- name: MuteUser :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM mutes
+WHERE muter_id = $1
+  AND muted_id = $2;
+
+-- name: GetHiddenUserIDs :many
+SELECT blocked_id AS user_id
+FROM blocks
+WHERE blocker_id = sqlc.arg(user_id)
+UNION
+SELECT muted_id
+FROM mutes
+WHERE muter_id = sqlc.arg(user_id);
\ No newline at end of file
@@ -0,0 +1,59 @@
+-- name: BookmarkChirp :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at, collection_id)
+VALUES ($1, $2, now(), $3)
+ON CONFLICT (user_id, chirp_id) DO UPDATE
+SET collection_id = EXCLUDED.collection_id;
+
+-- name: UnbookmarkChirp :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: DeleteChirpBookmarks :exec
+DELETE FROM bookmarks
+WHERE chirp_id = $1;
+
+-- name: GetBookmarkedChirps :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+  AND chirps.deleted_at IS NULL
+  AND (sqlc.narg(collection_id)::uuid IS NULL OR bookmarks.collection_id = sqlc.narg(collection_id)::uuid)
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (bookmarks.created_at, bookmarks.chirp_id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN bookmarks.created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN bookmarks.chirp_id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN bookmarks.created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN bookmarks.chirp_id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: CreateBookmarkCollection :one
+INSERT INTO bookmark_collections (id, created_at, user_id, name)
+VALUES (gen_random_uuid(), now(), $1, $2)
+RETURNING *;
+
+-- name: GetBookmarkCollectionById :one
+SELECT id, created_at, user_id, name
+FROM bookmark_collections
+WHERE id = $1;
+
+-- name: GetBookmarkCollections :many
+SELECT bookmark_collections.id, bookmark_collections.created_at, bookmark_collections.user_id, bookmark_collections.name,
+       COUNT(chirps.id) AS bookmark_count
+FROM bookmark_collections
+LEFT JOIN bookmarks ON bookmarks.collection_id = bookmark_collections.id
+LEFT JOIN chirps ON chirps.id = bookmarks.chirp_id AND chirps.deleted_at IS NULL
+WHERE bookmark_collections.user_id = $1
+GROUP BY bookmark_collections.id
+ORDER BY lower(bookmark_collections.name);
+
+-- name: CountBookmarkCollections :one
+SELECT COUNT(*) AS collection_count
+FROM bookmark_collections
+WHERE user_id = $1;
+
+-- name: DeleteBookmarkCollection :exec
+DELETE FROM bookmark_collections
+WHERE id = $1;
\ No newline at end of file
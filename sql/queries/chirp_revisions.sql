@@ -0,0 +1,14 @@
+-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (id, created_at, chirp_id, body)
+VALUES (gen_random_uuid(), now(), $1, $2)
+RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT id, created_at, chirp_id, body
+FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions
+WHERE chirp_id = $1;
\ No newline at end of file
@@ -1,27 +1,189 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), now(), now(), $1, $2)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, thread_id, kind, original_id)
+VALUES (gen_random_uuid(), now(), now(), $1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
 FROM chirps
-ORDER BY CASE WHEN $1 = 'desc' THEN created_at END DESC,
-         CASE WHEN $1 = 'asc' THEN created_at END ASC;
+WHERE deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1
+      FROM blocks
+      WHERE blocker_id = sqlc.arg(viewer_id)
+        AND blocked_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM mutes
+      WHERE muter_id = sqlc.arg(viewer_id)
+        AND muted_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN id END ASC
+LIMIT sqlc.arg(page_limit);
 
 -- name: GetChirpsByUserId :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
 FROM chirps
-WHERE user_id = $1
-ORDER BY CASE WHEN $2 = 'desc' THEN created_at END DESC,
-         CASE WHEN $2 = 'asc' THEN created_at END ASC;
+WHERE user_id = sqlc.arg(user_id)
+  AND deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1
+      FROM pinned_chirps
+      WHERE chirp_id = chirps.id
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM blocks
+      WHERE blocker_id = sqlc.arg(viewer_id)
+        AND blocked_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM mutes
+      WHERE muter_id = sqlc.arg(viewer_id)
+        AND muted_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetTimelineChirps :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE user_id IN (
+    SELECT followee_id
+    FROM follows
+    WHERE follower_id = sqlc.arg(follower_id)
+)
+  AND deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1
+      FROM blocks
+      WHERE blocker_id = sqlc.arg(follower_id)
+        AND blocked_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM mutes
+      WHERE muter_id = sqlc.arg(follower_id)
+        AND muted_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN id END ASC
+LIMIT sqlc.arg(page_limit);
 
 -- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
 FROM chirps
 WHERE id = $1
 LIMIT 1;
 
+-- name: GetChirpByIdForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE id = $1
+LIMIT 1
+FOR UPDATE;
+
+-- name: GetChirpReplies :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE parent_id = sqlc.arg(parent_id)
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetChirpThread :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE id = sqlc.arg(thread_id)
+   OR thread_id = sqlc.arg(thread_id)
+ORDER BY created_at ASC, id ASC;
+
+-- name: GetReplyCounts :many
+SELECT parent_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE parent_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+  AND deleted_at IS NULL
+GROUP BY parent_id;
+
+-- name: GetChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: ChirpIsReferenced :one
+SELECT EXISTS (
+    SELECT 1
+    FROM chirps
+    WHERE parent_id = $1
+       OR original_id = $1
+) AS is_referenced;
+
+-- name: GetRechirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE user_id = $1
+  AND original_id = $2
+  AND kind = 'rechirp'
+  AND deleted_at IS NULL
+LIMIT 1
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET updated_at = now(),
+    body = $1
+WHERE id = $2
+RETURNING *;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET updated_at = now(),
+    body = '',
+    deleted_at = now()
+WHERE id = $1;
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,72 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, created_by)
+VALUES (gen_random_uuid(), now(), now(), $1)
+RETURNING *;
+
+-- name: CreateDirectConversation :one
+INSERT INTO conversations (id, created_at, updated_at, created_by, direct_user_low, direct_user_high)
+VALUES (gen_random_uuid(), now(), now(), $1, $2, $3)
+ON CONFLICT (direct_user_low, direct_user_high) DO NOTHING
+RETURNING *;
+
+-- name: AddConversationParticipants :exec
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
+SELECT sqlc.arg(conversation_id)::uuid, unnest(sqlc.arg(user_ids)::uuid[]), now();
+
+-- name: GetConversationById :one
+SELECT id, created_at, updated_at, created_by, direct_user_low, direct_user_high
+FROM conversations
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetDirectConversation :one
+SELECT id, created_at, updated_at, created_by, direct_user_low, direct_user_high
+FROM conversations
+WHERE direct_user_low = $1
+  AND direct_user_high = $2
+LIMIT 1;
+
+-- name: GetConversationsForUser :many
+SELECT sqlc.embed(conversations), (
+    SELECT COUNT(*)
+    FROM messages
+    WHERE messages.conversation_id = conversations.id
+      AND messages.sender_id <> conversation_participants.user_id
+      AND (conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at)
+) AS unread_count
+FROM conversation_participants
+JOIN conversations ON conversations.id = conversation_participants.conversation_id
+WHERE conversation_participants.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (conversations.updated_at, conversations.id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (conversations.updated_at, conversations.id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN conversations.updated_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN conversations.id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN conversations.updated_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN conversations.id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetConversationParticipants :many
+SELECT conversation_id, user_id, joined_at, last_read_at
+FROM conversation_participants
+WHERE conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+ORDER BY joined_at ASC, user_id ASC;
+
+-- name: IsConversationParticipant :one
+SELECT EXISTS (
+    SELECT 1
+    FROM conversation_participants
+    WHERE conversation_id = $1
+      AND user_id = $2
+) AS is_participant;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = now()
+WHERE id = $1;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_participants
+SET last_read_at = now()
+WHERE conversation_id = $1
+  AND user_id = $2;
\ No newline at end of file
@@ -0,0 +1,67 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, parent_id, publish_at)
+VALUES (gen_random_uuid(), now(), now(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetDraftById :one
+SELECT id, created_at, updated_at, user_id, body, parent_id, publish_at, failed_attempts, next_attempt_at
+FROM drafts
+WHERE id = $1;
+
+-- name: GetDraftsByUser :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, publish_at, failed_attempts, next_attempt_at
+FROM drafts
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET updated_at = now(),
+    body = $2,
+    parent_id = $3,
+    publish_at = $4,
+    failed_attempts = 0,
+    next_attempt_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDraft :exec
+DELETE FROM drafts
+WHERE id = $1;
+
+-- name: LockDraft :one
+SELECT id, created_at, updated_at, user_id, body, parent_id, publish_at, failed_attempts, next_attempt_at
+FROM drafts
+WHERE id = $1
+FOR UPDATE;
+
+-- name: LockDueDraft :one
+SELECT id, created_at, updated_at, user_id, body, parent_id, publish_at, failed_attempts, next_attempt_at
+FROM drafts
+WHERE publish_at <= now()
+  AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+ORDER BY publish_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UnscheduleDraft :exec
+UPDATE drafts
+SET updated_at = now(),
+    publish_at = NULL,
+    failed_attempts = 0,
+    next_attempt_at = NULL
+WHERE id = $1;
+
+-- name: RecordDraftPublishFailure :execrows
+UPDATE drafts
+SET failed_attempts = failed_attempts + 1,
+    next_attempt_at = sqlc.arg(next_attempt_at)::timestamp
+WHERE id = sqlc.arg(id)
+  AND failed_attempts = sqlc.arg(failed_attempts);
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- name: NextChirpEventID :one
+SELECT nextval('chirp_event_ids')::bigint AS id;
+
+-- name: NotifyChirpEvent :exec
+SELECT pg_notify('chirp_events', sqlc.arg(payload)::text);
+
+-- name: NotifyNotificationEvent :exec
+SELECT pg_notify('notification_events', sqlc.arg(payload)::text);
\ No newline at end of file
@@ -0,0 +1,40 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1
+  AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT follower_id AS user_id, created_at
+FROM follows
+WHERE followee_id = sqlc.arg(followee_id)
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, follower_id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, follower_id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN follower_id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN follower_id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetFollowing :many
+SELECT followee_id AS user_id, created_at
+FROM follows
+WHERE follower_id = sqlc.arg(follower_id)
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, followee_id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, followee_id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN followee_id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN followee_id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetFolloweeIDs :many
+SELECT followee_id
+FROM follows
+WHERE follower_id = $1;
\ No newline at end of file
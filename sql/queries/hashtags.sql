@@ -0,0 +1,33 @@
+-- name: CreateChirpHashtags :exec
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+SELECT chirps.id, unnest(sqlc.arg(tags)::text[]), chirps.created_at
+FROM chirps
+WHERE chirps.id = sqlc.arg(chirp_id)::uuid
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: GetChirpsByHashtag :many
+SELECT sqlc.embed(chirps)
+FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = sqlc.arg(tag)
+  AND chirps.deleted_at IS NULL
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (chirps.created_at, chirps.id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN chirps.created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN chirps.id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN chirps.created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN chirps.id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetTrendingHashtags :many
+SELECT tag, COUNT(*) AS chirp_count
+FROM chirp_hashtags
+WHERE created_at >= sqlc.arg(since)::timestamp
+GROUP BY tag
+ORDER BY chirp_count DESC, tag ASC
+LIMIT sqlc.arg(tag_limit);
\ No newline at end of file
@@ -0,0 +1,36 @@
+-- name: LikeChirp :execrows
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :execrows
+DELETE FROM chirp_likes
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: GetLikeCounts :many
+SELECT chirp_id, COUNT(*) AS like_count
+FROM chirp_likes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id
+FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id)
+  AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetChirpsLikedByUser :many
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at
+FROM chirp_likes
+JOIN chirps ON chirps.id = chirp_likes.chirp_id
+WHERE chirp_likes.user_id = sqlc.arg(user_id)
+  AND chirps.deleted_at IS NULL
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (chirp_likes.created_at, chirp_likes.chirp_id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (chirp_likes.created_at, chirp_likes.chirp_id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN chirp_likes.created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN chirp_likes.chirp_id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN chirp_likes.created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN chirp_likes.chirp_id END ASC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
@@ -0,0 +1,46 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_key)
+VALUES ($1, now(), $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: CountAttachableMedia :one
+SELECT COUNT(*) AS media_count
+FROM media
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+  AND user_id = sqlc.arg(user_id)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM chirp_media
+    WHERE chirp_media.media_id = media.id
+);
+
+-- name: AttachChirpMedia :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+SELECT sqlc.arg(chirp_id), media_id, position
+FROM unnest(sqlc.arg(media_ids)::uuid[]) WITH ORDINALITY AS attached(media_id, position);
+
+-- name: GetChirpMedia :many
+SELECT chirp_media.chirp_id, sqlc.embed(media)
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_media.chirp_id, chirp_media.position;
+
+-- name: DeleteChirpMedia :many
+DELETE FROM media
+WHERE id IN (
+    SELECT media_id
+    FROM chirp_media
+    WHERE chirp_id = $1
+)
+RETURNING *;
+
+-- name: DeleteUnattachedMedia :many
+DELETE FROM media
+WHERE created_at < sqlc.arg(created_before)::timestamp
+  AND NOT EXISTS (
+    SELECT 1
+    FROM chirp_media
+    WHERE chirp_media.media_id = media.id
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- name: CreateChirpMentions :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, created_at)
+SELECT sqlc.arg(chirp_id)::uuid, unnest(sqlc.arg(user_ids)::uuid[]), now()
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpMentionUserIDs :many
+SELECT user_id
+FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: GetChirpsMentioningUser :many
+SELECT sqlc.embed(chirps)
+FROM chirp_mentions
+JOIN chirps ON chirps.id = chirp_mentions.chirp_id
+WHERE chirp_mentions.user_id = sqlc.arg(user_id)
+  AND chirps.deleted_at IS NULL
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (chirps.created_at, chirps.id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN chirps.created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN chirps.id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN chirps.created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN chirps.id END ASC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+VALUES (gen_random_uuid(), now(), $1, $2, $3)
+RETURNING *;
+
+-- name: GetMessages :many
+SELECT id, created_at, conversation_id, sender_id, body
+FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN id END ASC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
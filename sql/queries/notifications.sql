@@ -0,0 +1,41 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (id, created_at, user_id, actor_id, kind, chirp_id)
+VALUES (gen_random_uuid(), now(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: CreateNotifications :many
+INSERT INTO notifications (id, created_at, user_id, actor_id, kind, chirp_id)
+SELECT gen_random_uuid(), now(), unnest(sqlc.arg(user_ids)::uuid[]), sqlc.arg(actor_id)::uuid, sqlc.arg(kind)::text, sqlc.narg(chirp_id)::uuid
+RETURNING *;
+
+-- name: GetNotifications :many
+SELECT id, created_at, user_id, actor_id, kind, chirp_id, read_at
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetUnreadNotificationCount :one
+SELECT COUNT(*) AS unread_count
+FROM notifications
+WHERE user_id = $1
+  AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = now()
+WHERE user_id = sqlc.arg(user_id)
+  AND id = ANY(sqlc.arg(ids)::uuid[])
+  AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = now()
+WHERE user_id = $1
+  AND read_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,38 @@
+-- name: PinChirp :exec
+INSERT INTO pinned_chirps (user_id, chirp_id, pinned_at)
+VALUES ($1, $2, now())
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: DeleteChirpPin :exec
+DELETE FROM pinned_chirps
+WHERE chirp_id = $1;
+
+-- name: GetPinnedChirpIDs :many
+SELECT chirp_id
+FROM pinned_chirps
+WHERE user_id = $1;
+
+-- name: GetPinnedChirpsByUserId :many
+SELECT sqlc.embed(chirps), pinned_chirps.pinned_at
+FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = sqlc.arg(user_id)
+  AND chirps.deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1
+      FROM blocks
+      WHERE blocker_id = sqlc.arg(viewer_id)
+        AND blocked_id = chirps.user_id
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM mutes
+      WHERE muter_id = sqlc.arg(viewer_id)
+        AND muted_id = chirps.user_id
+  )
+ORDER BY pinned_chirps.pinned_at DESC, chirps.id DESC;
\ No newline at end of file
@@ -0,0 +1,63 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, closes_at)
+VALUES ($1, now(), $2);
+
+-- name: CreatePollOptions :exec
+INSERT INTO poll_options (chirp_id, position, label)
+SELECT sqlc.arg(chirp_id), ordinality - 1, label
+FROM unnest(sqlc.arg(labels)::text[]) WITH ORDINALITY AS options(label, ordinality);
+
+-- name: GetPollsByChirpIds :many
+SELECT chirp_id, created_at, closes_at, closed_at
+FROM polls
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT chirp_id, position, label, final_votes
+FROM poll_options
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetPollVoteCounts :many
+SELECT chirp_id, position, COUNT(*) AS vote_count
+FROM poll_votes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id, position;
+
+-- name: GetPollVotesByUser :many
+SELECT chirp_id, position
+FROM poll_votes
+WHERE user_id = sqlc.arg(user_id)
+  AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetPoll :one
+SELECT chirp_id, created_at, closes_at, closed_at
+FROM polls
+WHERE chirp_id = $1;
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, position, created_at)
+SELECT polls.chirp_id, sqlc.arg(user_id), sqlc.arg(position), now()
+FROM polls
+WHERE polls.chirp_id = sqlc.arg(chirp_id)
+  AND polls.closed_at IS NULL
+  AND polls.closes_at > now()
+ON CONFLICT DO NOTHING;
+
+-- name: ClosePolls :execrows
+WITH closed AS (
+    UPDATE polls
+    SET closed_at = now()
+    WHERE closed_at IS NULL
+      AND closes_at <= now()
+    RETURNING chirp_id
+)
+UPDATE poll_options
+SET final_votes = (
+    SELECT COUNT(*)
+    FROM poll_votes
+    WHERE poll_votes.chirp_id = poll_options.chirp_id
+      AND poll_votes.position = poll_options.position
+)
+FROM closed
+WHERE poll_options.chirp_id = closed.chirp_id;
\ No newline at end of file
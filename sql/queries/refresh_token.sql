@@ -1,22 +1,85 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
-VALUES ($1, now(), now(), $2, now() + interval '60 day')
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, user_id, expires_at, family_id, user_agent, ip_address, last_used_at)
+VALUES ($1, now(), now(), $2, $3, $4, $5, $6, now())
 RETURNING *;
 
 -- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, user_agent, ip_address, last_used_at, revoked_reason
 FROM refresh_tokens
-WHERE token = $1
+WHERE token_hash = $1
 LIMIT 1;
 
 -- name: GetUserFromRefreshToken :one
 SELECT user_id
 FROM refresh_tokens
-WHERE token = $1
+WHERE token_hash = $1
 LIMIT 1;
 
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET updated_at = now(),
-    revoked_at = now()
-WHERE token = $1;
\ No newline at end of file
+    revoked_at = now(),
+    revoked_reason = 'logout'
+WHERE token_hash = $1
+  AND revoked_at IS NULL;
+
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET updated_at = now(),
+    revoked_at = now(),
+    revoked_reason = 'rotated'
+WHERE token_hash = $1
+  AND revoked_at IS NULL;
+
+-- name: TouchSession :exec
+UPDATE refresh_tokens
+SET last_used_at = now()
+WHERE family_id = $1;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET updated_at = now(),
+    revoked_at = now(),
+    revoked_reason = 'reuse'
+WHERE family_id = $1
+  AND revoked_at IS NULL;
+
+-- name: GetSessions :many
+SELECT family_id AS id,
+       (SELECT min(family.created_at)
+        FROM refresh_tokens family
+        WHERE family.family_id = refresh_tokens.family_id)::timestamp AS created_at,
+       last_used_at,
+       expires_at,
+       user_agent,
+       ip_address
+FROM refresh_tokens
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > now()
+ORDER BY last_used_at DESC, family_id DESC;
+
+-- name: IsSessionRevoked :one
+SELECT NOT EXISTS (
+    SELECT 1
+    FROM refresh_tokens
+    WHERE family_id = $1
+      AND revoked_at IS NULL
+) AS is_revoked;
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET updated_at = now(),
+    revoked_at = now(),
+    revoked_reason = 'session'
+WHERE user_id = $1
+  AND family_id = $2
+  AND revoked_at IS NULL;
+
+-- name: RevokeAllSessions :exec
+UPDATE refresh_tokens
+SET updated_at = now(),
+    revoked_at = now(),
+    revoked_reason = 'session'
+WHERE user_id = $1
+  AND revoked_at IS NULL;
\ No newline at end of file
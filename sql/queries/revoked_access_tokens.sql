@@ -0,0 +1,15 @@
+-- name: RevokeAccessToken :exec
+INSERT INTO revoked_access_tokens (token_id, user_id, revoked_at, expires_at)
+VALUES ($1, $2, now(), $3)
+ON CONFLICT (token_id) DO NOTHING;
+
+-- name: IsAccessTokenRevoked :one
+SELECT EXISTS (
+    SELECT 1
+    FROM revoked_access_tokens
+    WHERE token_id = $1
+) AS is_revoked;
+
+-- name: DeleteExpiredRevokedAccessTokens :execrows
+DELETE FROM revoked_access_tokens
+WHERE expires_at < now();
\ No newline at end of file
@@ -0,0 +1,76 @@
+-- name: SearchChirpsByRank :many
+WITH matches AS (
+    SELECT id, ts_rank(to_tsvector('english', body), websearch_to_tsquery('english', sqlc.arg(query)::text))::real AS rank
+    FROM chirps
+    WHERE to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+      AND deleted_at IS NULL
+      AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id)::uuid)
+      AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since)::timestamp)
+      AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until)::timestamp)
+      AND NOT EXISTS (
+          SELECT 1
+          FROM blocks
+          WHERE blocker_id = sqlc.arg(viewer_id)
+            AND blocked_id IN (
+                chirps.user_id,
+                (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+            )
+      )
+      AND NOT EXISTS (
+          SELECT 1
+          FROM mutes
+          WHERE muter_id = sqlc.arg(viewer_id)
+            AND muted_id IN (
+                chirps.user_id,
+                (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+            )
+      )
+)
+SELECT sqlc.embed(chirps), matches.rank
+FROM matches
+JOIN chirps ON chirps.id = matches.id
+WHERE sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (matches.rank, chirps.created_at, chirps.id) > (sqlc.arg(cursor_rank)::real, sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (matches.rank, chirps.created_at, chirps.id) < (sqlc.arg(cursor_rank)::real, sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN matches.rank END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN chirps.created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN chirps.id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN matches.rank END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN chirps.created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN chirps.id END ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: SearchChirpsByRecency :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, thread_id, deleted_at, kind, original_id
+FROM chirps
+WHERE to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+  AND deleted_at IS NULL
+  AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id)::uuid)
+  AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since)::timestamp)
+  AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until)::timestamp)
+  AND NOT EXISTS (
+      SELECT 1
+      FROM blocks
+      WHERE blocker_id = sqlc.arg(viewer_id)
+        AND blocked_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND NOT EXISTS (
+      SELECT 1
+      FROM mutes
+      WHERE muter_id = sqlc.arg(viewer_id)
+        AND muted_id IN (
+            chirps.user_id,
+            (SELECT original.user_id FROM chirps AS original WHERE original.id = chirps.original_id)
+        )
+  )
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+   OR (sqlc.arg(sort)::text = 'asc' AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+   OR (sqlc.arg(sort)::text = 'desc' AND (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)))
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'desc' THEN created_at END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'desc' THEN id END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN created_at END ASC,
+         CASE WHEN sqlc.arg(sort)::text = 'asc' THEN id END ASC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
@@ -1,13 +1,14 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
-VALUES (gen_random_uuid(), now(), now(), $1, $2)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
+VALUES (gen_random_uuid(), now(), now(), $1, $2, $3)
 RETURNING *;
 
 -- name: ResetUsers :exec
 DELETE FROM users;
 
 -- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle,
+       display_name, bio, location, website, avatar_url
 FROM users
 WHERE email = $1
 LIMIT 1;
@@ -15,9 +16,22 @@ LIMIT 1;
 -- name: UpdateUser :one
 UPDATE users
 SET updated_at = now(),
-    email = $1,
-    hashed_password = $2
-WHERE id = $3
+    email = sqlc.arg(email),
+    hashed_password = sqlc.arg(hashed_password),
+    handle = COALESCE(sqlc.narg(handle), handle)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET updated_at = now(),
+    handle = COALESCE(sqlc.narg(handle), handle),
+    display_name = COALESCE(sqlc.narg(display_name), display_name),
+    bio = COALESCE(sqlc.narg(bio), bio),
+    location = COALESCE(sqlc.narg(location), location),
+    website = COALESCE(sqlc.narg(website), website),
+    avatar_url = COALESCE(sqlc.narg(avatar_url), avatar_url)
+WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: UpgradeUserToChirpyRed :one
@@ -28,7 +42,43 @@ WHERE id = $1
 RETURNING *;
 
 -- name: GetUserByID :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle,
+       display_name, bio, location, website, avatar_url
+FROM users
+WHERE id = $1
+LIMIT 1;
+
+-- name: LockUser :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle,
+       display_name, bio, location, website, avatar_url
 FROM users
 WHERE id = $1
-LIMIT 1;
\ No newline at end of file
+FOR UPDATE;
+
+-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle,
+       display_name, bio, location, website, avatar_url
+FROM users
+WHERE lower(handle) = lower(sqlc.arg(handle))
+LIMIT 1;
+
+-- name: GetMentionableUserIDs :many
+SELECT id
+FROM users
+WHERE lower(handle) = ANY(sqlc.arg(handles)::text[])
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks
+    WHERE blocker_id = users.id
+      AND blocked_id = sqlc.arg(author_id)
+);
+
+-- name: CountUsersByIds :one
+SELECT COUNT(*) AS user_count
+FROM users
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetUserProfileCounts :one
+SELECT (SELECT COUNT(*) FROM follows WHERE followee_id = sqlc.arg(id)) AS follower_count,
+       (SELECT COUNT(*) FROM follows WHERE follower_id = sqlc.arg(id)) AS following_count,
+       (SELECT COUNT(*) FROM chirps WHERE user_id = sqlc.arg(id) AND deleted_at IS NULL) AS chirp_count;
\ No newline at end of file
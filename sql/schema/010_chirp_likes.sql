@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX chirp_likes_chirp_id_idx ON chirp_likes (chirp_id);
+CREATE INDEX chirp_likes_user_id_created_at_idx ON chirp_likes (user_id, created_at);
+
+-- +goose Down
+DROP TABLE chirp_likes;
\ No newline at end of file
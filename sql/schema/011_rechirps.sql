@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN kind TEXT NOT NULL DEFAULT 'chirp' CHECK (kind IN ('chirp', 'rechirp', 'quote')),
+ADD COLUMN original_id UUID REFERENCES chirps (id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_original_id_idx ON chirps (original_id);
+CREATE UNIQUE INDEX chirps_rechirp_user_id_original_id_idx ON chirps (user_id, original_id)
+WHERE kind = 'rechirp';
+
+-- +goose Down
+DROP INDEX chirps_rechirp_user_id_original_id_idx;
+DROP INDEX chirps_original_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN original_id,
+DROP COLUMN kind;
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE chirp_mentions (
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE NOT NULL,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX chirp_mentions_user_id_idx ON chirp_mentions (user_id);
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    actor_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    kind TEXT NOT NULL,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX notifications_user_id_created_at_idx ON notifications (user_id, created_at);
+
+-- +goose Down
+DROP TABLE notifications;
+DROP TABLE chirp_mentions;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE notifications
+ADD CONSTRAINT notifications_kind_check CHECK (kind IN ('like', 'reply', 'follow', 'mention', 'rechirp', 'quote'));
+
+CREATE INDEX notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+-- +goose Down
+DROP INDEX notifications_unread_idx;
+
+ALTER TABLE notifications
+DROP CONSTRAINT notifications_kind_check;
\ No newline at end of file
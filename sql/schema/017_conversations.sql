@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE TABLE conversation_participants (
+    conversation_id UUID REFERENCES conversations (id) ON DELETE CASCADE NOT NULL,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX conversation_participants_user_id_idx ON conversation_participants (user_id);
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    conversation_id UUID REFERENCES conversations (id) ON DELETE CASCADE NOT NULL,
+    sender_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    body TEXT NOT NULL
+);
+
+CREATE INDEX messages_conversation_id_created_at_idx ON messages (conversation_id, created_at, id);
+
+-- +goose Down
+DROP TABLE messages;
+DROP TABLE conversation_participants;
+DROP TABLE conversations;
\ No newline at end of file
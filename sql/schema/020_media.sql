@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE media (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    storage_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL
+);
+
+CREATE INDEX media_user_id_idx ON media (user_id);
+
+-- Media can only be attached to one chirp
+CREATE TABLE chirp_media (
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE NOT NULL,
+    media_id UUID REFERENCES media (id) ON DELETE CASCADE NOT NULL UNIQUE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, media_id)
+);
+
+-- +goose Down
+DROP TABLE chirp_media;
+DROP TABLE media;
\ No newline at end of file
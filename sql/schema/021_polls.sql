@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE polls (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    closes_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP
+);
+
+CREATE INDEX polls_open_idx ON polls (closes_at) WHERE closed_at IS NULL;
+
+CREATE TABLE poll_options (
+    chirp_id UUID REFERENCES polls (chirp_id) ON DELETE CASCADE NOT NULL,
+    position INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    -- Filled in when the poll closes
+    final_votes BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (chirp_id, position)
+);
+
+-- The primary key allows one vote per user per poll
+CREATE TABLE poll_votes (
+    chirp_id UUID NOT NULL,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id),
+    FOREIGN KEY (chirp_id, position) REFERENCES poll_options (chirp_id, position) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE drafts (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    body TEXT NOT NULL,
+    parent_id UUID REFERENCES chirps (id) ON DELETE CASCADE,
+    -- Drafts with a publish time are scheduled chirps
+    publish_at TIMESTAMP
+);
+
+CREATE INDEX drafts_user_id_idx ON drafts (user_id, created_at);
+CREATE INDEX drafts_publish_at_idx ON drafts (publish_at) WHERE publish_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE drafts;
\ No newline at end of file
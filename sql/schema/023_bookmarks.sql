@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE bookmark_collections (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    name TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX bookmark_collections_name_idx ON bookmark_collections (user_id, lower(name));
+
+CREATE TABLE bookmarks (
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    -- Removing a collection keeps its bookmarks
+    collection_id UUID REFERENCES bookmark_collections (id) ON DELETE SET NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_id_idx ON bookmarks (user_id, created_at);
+CREATE INDEX bookmarks_chirp_id_idx ON bookmarks (chirp_id);
+
+-- +goose Down
+DROP TABLE bookmarks;
+DROP TABLE bookmark_collections;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE pinned_chirps (
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    chirp_id UUID UNIQUE REFERENCES chirps (id) ON DELETE CASCADE NOT NULL,
+    pinned_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+-- +goose Down
+DROP TABLE pinned_chirps;
\ No newline at end of file
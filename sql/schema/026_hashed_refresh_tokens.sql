@@ -0,0 +1,9 @@
+-- +goose Up
+-- Hash existing tokens in place so current sessions keep working
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+-- +goose Down
+-- Hashes can't be turned back into tokens, so every session is signed out
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE revoked_access_tokens (
+    token_id TEXT PRIMARY KEY,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    revoked_at TIMESTAMP NOT NULL,
+    -- Rows can be removed once the token would have expired anyway
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX revoked_access_tokens_expires_at_idx ON revoked_access_tokens (expires_at);
+
+-- +goose Down
+DROP TABLE revoked_access_tokens;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Chirp stream event IDs, shared by every server instance
+CREATE SEQUENCE chirp_event_ids;
+
+-- +goose Down
+DROP SEQUENCE chirp_event_ids;
\ No newline at end of file
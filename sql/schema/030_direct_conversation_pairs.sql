@@ -0,0 +1,30 @@
+-- +goose Up
+-- One-to-one conversations store their participants in a fixed order so
+-- concurrent requests can't create the same conversation twice
+ALTER TABLE conversations
+ADD COLUMN direct_user_low UUID REFERENCES users (id) ON DELETE CASCADE,
+ADD COLUMN direct_user_high UUID REFERENCES users (id) ON DELETE CASCADE;
+
+UPDATE conversations
+SET direct_user_low = pairs.low, direct_user_high = pairs.high
+FROM (
+    SELECT DISTINCT ON (direct.low, direct.high) direct.conversation_id, direct.low, direct.high
+    FROM (
+        SELECT conversation_id, MIN(user_id::text)::uuid AS low, MAX(user_id::text)::uuid AS high
+        FROM conversation_participants
+        GROUP BY conversation_id
+        HAVING COUNT(*) = 2
+    ) AS direct
+    JOIN conversations ON conversations.id = direct.conversation_id
+    ORDER BY direct.low, direct.high, conversations.created_at
+) AS pairs
+WHERE conversations.id = pairs.conversation_id;
+
+CREATE UNIQUE INDEX conversations_direct_pair_idx ON conversations (direct_user_low, direct_user_high);
+
+-- +goose Down
+DROP INDEX conversations_direct_pair_idx;
+
+ALTER TABLE conversations
+DROP COLUMN direct_user_high,
+DROP COLUMN direct_user_low;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Scheduled drafts that fail to publish are retried with a backoff
+ALTER TABLE drafts
+ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN next_attempt_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE drafts
+DROP COLUMN next_attempt_at,
+DROP COLUMN failed_attempts;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Only tokens revoked by rotation count as reused when presented again
+ALTER TABLE refresh_tokens
+ADD COLUMN revoked_reason TEXT CHECK (revoked_reason IN ('rotated', 'logout', 'session', 'reuse'));
+
+UPDATE refresh_tokens
+SET revoked_reason = CASE
+    WHEN EXISTS (SELECT 1
+                 FROM refresh_tokens AS newer
+                 WHERE newer.family_id = refresh_tokens.family_id
+                   AND newer.created_at > refresh_tokens.created_at) THEN 'rotated'
+    ELSE 'logout'
+END
+WHERE revoked_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE refresh_tokens
+DROP COLUMN revoked_reason;
\ No newline at end of file
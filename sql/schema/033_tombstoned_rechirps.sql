@@ -0,0 +1,11 @@
+-- +goose Up
+-- Undone rechirps that others replied to stay as tombstones, so they no
+-- longer keep the user from rechirping the chirp again
+DROP INDEX chirps_rechirp_user_id_original_id_idx;
+CREATE UNIQUE INDEX chirps_rechirp_user_id_original_id_idx ON chirps (user_id, original_id)
+WHERE kind = 'rechirp' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_rechirp_user_id_original_id_idx;
+CREATE UNIQUE INDEX chirps_rechirp_user_id_original_id_idx ON chirps (user_id, original_id)
+WHERE kind = 'rechirp';
\ No newline at end of file
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+// writeChirpEvent writes event in Server-Sent Events format.
+func writeChirpEvent(w io.Writer, event ChirpEvent) error {
+	data, err := json.Marshal(event.Chirp)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+func (cfg *apiConfig) streamChirpsHandler(rw http.ResponseWriter, rq *http.Request) {
+	query := rq.URL.Query()
+
+	// Only stream chirps by these authors (every author if nil)
+	var authors map[uuid.UUID]bool
+
+	// The caller, if any, whose blocked and muted users are left out
+	viewerID := uuid.Nil
+
+	// Check for author id query parameter
+	if authorParam := query.Get("author_id"); len(authorParam) > 0 {
+		authorID, err := uuid.Parse(authorParam)
+		if err != nil {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid author ID")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		authors = map[uuid.UUID]bool{authorID: true}
+	}
+
+	// Check for following query parameter (the follow list is read once on connect)
+	if query.Get("following") == "true" {
+		token, err := auth.GetBearerToken(rq.Header)
+		if err != nil {
+			err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		userID, err := cfg.validateJWT(rq.Context(), token)
+		if err != nil {
+			err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		followeeIDs, err := cfg.db.GetFolloweeIDs(rq.Context(), userID)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error getting followed users")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+
+		followees := make(map[uuid.UUID]bool, len(followeeIDs))
+		for _, id := range followeeIDs {
+			if authors == nil || authors[id] {
+				followees[id] = true
+			}
+		}
+		authors = followees
+		viewerID = userID
+	} else {
+		viewerID = cfg.getOptionalUserID(rq)
+	}
+
+	// Blocks and mutes are read once on connect, like the follow list
+	var hidden hiddenUsers
+	if viewerID != uuid.Nil {
+		hiddenIDs, err := cfg.db.GetHiddenUserIDs(rq.Context(), viewerID)
+		if err != nil {
+			err = respondWithError(rw, http.StatusInternalServerError, "Error getting blocked users")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+		hidden = newHiddenUsers(hiddenIDs)
+	}
+
+	// Check for Last-Event-ID header (or query parameter for the first connect)
+	var lastEventID int64
+	lastEventParam := rq.Header.Get("Last-Event-ID")
+	if len(lastEventParam) == 0 {
+		lastEventParam = query.Get("last_event_id")
+	}
+	if len(lastEventParam) > 0 {
+		var err error
+		lastEventID, err = strconv.ParseInt(lastEventParam, 10, 64)
+		if err != nil || lastEventID < 0 {
+			err = respondWithError(rw, http.StatusBadRequest, "Invalid Last-Event-ID")
+			if err != nil {
+				log.Printf("Error responding: %v", err)
+			}
+			return
+		}
+	}
+
+	// Streams stay open far longer than a normal response
+	rc := http.NewResponseController(rw)
+	err := rc.SetWriteDeadline(time.Time{})
+	if err != nil && err != http.ErrNotSupported {
+		log.Printf("Error clearing write deadline: %v", err)
+	}
+
+	// Subscribe before writing anything so no events are missed
+	sub, backlog := cfg.broker.Subscribe(lastEventID)
+	defer cfg.broker.Unsubscribe(sub)
+
+	rw.Header().Set("Access-Control-Allow-Origin", "*")
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	// Replay events the client missed
+	for _, event := range backlog {
+		if (authors != nil && !authors[event.Chirp.UserID]) || hidden.hides(event.Chirp) {
+			continue
+		}
+		err = writeChirpEvent(rw, event)
+		if err != nil {
+			return
+		}
+	}
+
+	err = rc.Flush()
+	if err != nil {
+		log.Printf("Error flushing stream: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-rq.Context().Done():
+			return
+		case <-heartbeat.C:
+			// Comments keep proxies from closing idle connections
+			_, err = io.WriteString(rw, ": ping\n\n")
+		case event, ok := <-sub.events:
+			// The broker drops subscribers that fall too far behind
+			if !ok {
+				return
+			}
+			if (authors != nil && !authors[event.Chirp.UserID]) || hidden.hides(event.Chirp) {
+				continue
+			}
+			err = writeChirpEvent(rw, event)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+)
+
+type ChirpThreadNode struct {
+	Chirp
+	Replies []ChirpThreadNode `json:"replies"`
+}
+
+// buildChirpThread nests the chirps of a thread under their parents, starting
+// at rootID. Chirps are expected in creation order so replies stay sorted.
+func buildChirpThread(rootID uuid.UUID, chirps []Chirp) (ChirpThreadNode, bool) {
+	var root *Chirp
+	children := make(map[uuid.UUID][]Chirp)
+	for i := range chirps {
+		if chirps[i].ID == rootID {
+			root = &chirps[i]
+			continue
+		}
+		if chirps[i].InReplyTo != nil {
+			parentID := *chirps[i].InReplyTo
+			children[parentID] = append(children[parentID], chirps[i])
+		}
+	}
+
+	if root == nil {
+		return ChirpThreadNode{}, false
+	}
+
+	var build func(chirp Chirp) ChirpThreadNode
+	build = func(chirp Chirp) ChirpThreadNode {
+		node := ChirpThreadNode{Chirp: chirp, Replies: make([]ChirpThreadNode, 0, len(children[chirp.ID]))}
+		for _, reply := range children[chirp.ID] {
+			node.Replies = append(node.Replies, build(reply))
+		}
+		return node
+	}
+
+	return build(*root), true
+}
+
+func (cfg *apiConfig) getChirpRepliesHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Check for sort, limit and cursor parameters
+	page, err := parsePageParams(rq.URL.Query())
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, err.Error())
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	// Make sure chirp exists (tombstones still have replies)
+	_, err = cfg.db.GetChirpById(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get replies from database
+	dbParams := database.GetChirpRepliesParams{
+		ParentID:        uuid.NullUUID{UUID: id, Valid: true},
+		CursorCreatedAt: cursorCreatedAt,
+		Sort:            page.queryOrder(),
+		CursorID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	}
+
+	dbChirps, err := cfg.db.GetChirpReplies(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting replies")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database chirps to Chirp struct
+	chirps := make([]Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = chirpFromDB(dbChirp)
+	}
+
+	// Trim to the requested page and build cursors
+	chirps, next, prev := paginate(chirps, page, chirpCursor)
+	setPageLinks(rw, rq, next, prev)
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, cfg.getOptionalUserID(rq))
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting replies")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return replies
+	err = respondWithJSON(rw, http.StatusOK, ChirpPage{Chirps: chirps, NextCursor: next, PrevCursor: prev})
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
+
+func (cfg *apiConfig) getChirpThreadHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Get chirp ID from URL
+	id, err := uuid.Parse(rq.PathValue("id"))
+	if err != nil {
+		err = respondWithError(rw, http.StatusBadRequest, "Invalid chirp ID")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Get chirp from database
+	dbChirp, err := cfg.db.GetChirpById(rq.Context(), id)
+	if err != nil {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Find the root of the thread
+	rootID := dbChirp.ID
+	if dbChirp.ThreadID.Valid {
+		rootID = dbChirp.ThreadID.UUID
+	}
+
+	// Get every chirp in the thread from database
+	dbChirps, err := cfg.db.GetChirpThread(rq.Context(), rootID)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting thread")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Map database chirps to Chirp struct
+	chirps := make([]Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = chirpFromDB(dbChirp)
+	}
+
+	// Add reply and like counts
+	err = cfg.hydrateChirps(rq.Context(), chirps, cfg.getOptionalUserID(rq))
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Error getting thread")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Build conversation tree
+	thread, ok := buildChirpThread(rootID, chirps)
+	if !ok {
+		err = respondWithError(rw, http.StatusNotFound, "Chirp not found")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Return thread
+	err = respondWithJSON(rw, http.StatusOK, thread)
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestBuildChirpThread(t *testing.T) {
+	root := Chirp{ID: uuid.New()}
+	reply := Chirp{ID: uuid.New(), InReplyTo: &root.ID}
+	nested := Chirp{ID: uuid.New(), InReplyTo: &reply.ID}
+	sibling := Chirp{ID: uuid.New(), InReplyTo: &root.ID}
+	chirps := []Chirp{root, reply, nested, sibling}
+
+	tests := []struct {
+		name        string
+		rootID      uuid.UUID
+		wantOK      bool
+		wantReplies []uuid.UUID
+	}{
+		{"FromRoot", root.ID, true, []uuid.UUID{reply.ID, sibling.ID}},
+		{"FromReply", reply.ID, true, []uuid.UUID{nested.ID}},
+		{"Leaf", nested.ID, true, []uuid.UUID{}},
+		{"MissingRoot", uuid.New(), false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := buildChirpThread(tt.rootID, chirps)
+			if ok != tt.wantOK {
+				t.Fatalf("buildChirpThread() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if len(got.Replies) != len(tt.wantReplies) {
+				t.Fatalf("buildChirpThread() replies = %d, want %d", len(got.Replies), len(tt.wantReplies))
+			}
+			for i, id := range tt.wantReplies {
+				if got.Replies[i].ID != id {
+					t.Errorf("buildChirpThread() reply %d = %v, want %v", i, got.Replies[i].ID, id)
+				}
+			}
+		})
+	}
+
+	t.Run("NestsDeeply", func(t *testing.T) {
+		got, _ := buildChirpThread(root.ID, chirps)
+		if len(got.Replies[0].Replies) != 1 || got.Replies[0].Replies[0].ID != nested.ID {
+			t.Errorf("expected nested reply under first reply")
+		}
+	})
+}
@@ -1,14 +1,157 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
 	"log"
 	"net/http"
 	"time"
 )
 
+const (
+	denylistPruneInterval = time.Hour
+	// Absolute lifetime of a login; rotated refresh tokens inherit it
+	refreshTokenTTL = 60 * 24 * time.Hour
+)
+
+// Revocation reason of a refresh token replaced by rotation (the others are
+// "logout", "session" and "reuse")
+const revokedRotated = "rotated"
+
+var (
+	errAccessTokenRevoked      = errors.New("access token has been revoked")
+	errAccessTokenNotRevocable = errors.New("access token has no ID and can't be revoked")
+)
+
+// accessTokenDenylist reports whether an access token, or the session it was
+// issued for, was revoked before it expired. *database.Queries implements it.
+type accessTokenDenylist interface {
+	IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	IsSessionRevoked(ctx context.Context, familyID uuid.UUID) (bool, error)
+}
+
 type RefreshedAccessToken struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// refreshTokenReused reports whether a revoked refresh token was presented
+// again after being rotated. Tokens revoked by logging out or ending the
+// session are simply rejected.
+func refreshTokenReused(refreshToken database.RefreshToken) bool {
+	return refreshToken.RevokedAt.Valid && refreshToken.RevokedReason.String == revokedRotated
+}
+
+// rotatedRefreshToken returns the parameters for the token replacing
+// refreshToken. It stays in the same family and expires with it, so rotating
+// never extends a login past its original lifetime.
+func rotatedRefreshToken(refreshToken database.RefreshToken, tokenHash, userAgent, ipAddress string) database.CreateRefreshTokenParams {
+	return database.CreateRefreshTokenParams{
+		TokenHash: tokenHash,
+		UserID:    refreshToken.UserID,
+		ExpiresAt: refreshToken.ExpiresAt,
+		FamilyID:  refreshToken.FamilyID,
+		UserAgent: userAgent,
+		IpAddress: ipAddress,
+	}
+}
+
+// revokeTokenFamily is called when a refresh token that was already rotated is
+// presented again. Either the old token leaked or the client is replaying it,
+// so every token descended from the same login is revoked.
+func revokeTokenFamily(ctx context.Context, q *database.Queries, rq *http.Request, refreshToken database.RefreshToken) error {
+	log.Printf("Security event: reused refresh token for user %s from %s, revoking token family %s", refreshToken.UserID, clientIP(rq), refreshToken.FamilyID)
+	return q.RevokeRefreshTokenFamily(ctx, refreshToken.FamilyID)
+}
+
+// validateAccessToken validates the access token's signature and claims and
+// makes sure neither it nor its session has been revoked.
+func (cfg *apiConfig) validateAccessToken(ctx context.Context, token string) (auth.AccessToken, error) {
+	accessToken, err := auth.ParseAccessToken(token, cfg.keyring)
+	if err != nil {
+		return auth.AccessToken{}, err
+	}
+
+	err = cfg.checkAccessTokenRevoked(ctx, accessToken)
+	if err != nil {
+		return auth.AccessToken{}, err
+	}
+
+	return accessToken, nil
+}
+
+// checkAccessTokenRevoked returns errAccessTokenRevoked if an already parsed
+// access token or its session has been revoked since. Legacy tokens without
+// an ID or session can't be revoked and simply run out.
+func (cfg *apiConfig) checkAccessTokenRevoked(ctx context.Context, accessToken auth.AccessToken) error {
+	if len(accessToken.ID) > 0 {
+		revoked, err := cfg.denylist.IsAccessTokenRevoked(ctx, accessToken.ID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return errAccessTokenRevoked
+		}
+	}
+
+	// Logging out everywhere or ending the session revokes every token issued for it
+	if accessToken.SessionID != uuid.Nil {
+		revoked, err := cfg.denylist.IsSessionRevoked(ctx, accessToken.SessionID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return errAccessTokenRevoked
+		}
+	}
+
+	return nil
+}
+
+// validateJWT returns the ID of the user a valid, unrevoked access token
+// belongs to.
+func (cfg *apiConfig) validateJWT(ctx context.Context, token string) (uuid.UUID, error) {
+	accessToken, err := cfg.validateAccessToken(ctx, token)
+	return accessToken.UserID, err
+}
+
+// pruneRevokedAccessTokens periodically removes denylist entries for tokens
+// that have expired anyway.
+func (cfg *apiConfig) pruneRevokedAccessTokens(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := cfg.db.DeleteExpiredRevokedAccessTokens(ctx)
+		if err != nil {
+			log.Printf("Error pruning revoked access tokens: %v", err)
+		}
+	}
+}
+
+// revokeAccessToken adds the access token to the denylist until it expires.
+// Legacy tokens without an ID can't be denylisted.
+func (cfg *apiConfig) revokeAccessToken(ctx context.Context, accessToken auth.AccessToken) error {
+	if len(accessToken.ID) == 0 {
+		return errAccessTokenNotRevocable
+	}
+
+	dbParams := database.RevokeAccessTokenParams{
+		TokenID:   accessToken.ID,
+		UserID:    accessToken.UserID,
+		ExpiresAt: accessToken.ExpiresAt,
+	}
+
+	return cfg.db.RevokeAccessToken(ctx, dbParams)
 }
 
 func (cfg *apiConfig) tokenRefreshHandler(rw http.ResponseWriter, rq *http.Request) {
@@ -25,8 +168,9 @@ func (cfg *apiConfig) tokenRefreshHandler(rw http.ResponseWriter, rq *http.Reque
 		return
 	}
 
-	// Get refresh token from database
-	refreshToken, err := cfg.db.GetRefreshToken(rq.Context(), token)
+	// Get refresh token from database (only its hash is stored)
+	tokenHash := auth.HashRefreshToken(token)
+	refreshToken, err := cfg.db.GetRefreshToken(rq.Context(), tokenHash)
 	if err != nil {
 		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
 		if err != nil {
@@ -35,6 +179,22 @@ func (cfg *apiConfig) tokenRefreshHandler(rw http.ResponseWriter, rq *http.Reque
 		return
 	}
 
+	// Check if refresh token is revoked (a rotated token being used again means it was reused)
+	if refreshToken.RevokedAt.Valid {
+		if refreshTokenReused(refreshToken) {
+			err = revokeTokenFamily(rq.Context(), cfg.db, rq, refreshToken)
+			if err != nil {
+				log.Printf("Error revoking token family: %v", err)
+			}
+		}
+
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
 	// Check if refresh token is expired
 	if refreshToken.ExpiresAt.Before(time.Now()) {
 		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
@@ -44,8 +204,61 @@ func (cfg *apiConfig) tokenRefreshHandler(rw http.ResponseWriter, rq *http.Reque
 		return
 	}
 
-	// Check if refresh token is revoked
-	if refreshToken.RevokedAt.Valid {
+	// Create refreshed access token
+	accessToken, err := auth.MakeSessionJWT(refreshToken.UserID, refreshToken.FamilyID, cfg.keyring, time.Second*time.Duration(3600))
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Create replacement refresh token
+	newRefreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Start transaction so the old token is only revoked if its replacement is saved
+	tx, err := cfg.dbConn.BeginTx(rq.Context(), nil)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Revoke the presented token (no rows means a concurrent request already revoked it)
+	rows, err := qtx.RotateRefreshToken(rq.Context(), tokenHash)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	if rows == 0 {
+		// Only a concurrent rotation counts as reuse, not a concurrent logout
+		refreshToken, err = qtx.GetRefreshToken(rq.Context(), tokenHash)
+		if err == nil && refreshTokenReused(refreshToken) {
+			err = revokeTokenFamily(rq.Context(), qtx, rq, refreshToken)
+			if err == nil {
+				err = tx.Commit()
+			}
+		}
+		if err != nil {
+			log.Printf("Error revoking token family: %v", err)
+		}
+
 		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
 		if err != nil {
 			log.Printf("Error responding: %v", err)
@@ -53,8 +266,20 @@ func (cfg *apiConfig) tokenRefreshHandler(rw http.ResponseWriter, rq *http.Reque
 		return
 	}
 
-	// Create refreshed access token
-	accessToken, err := auth.MakeJWT(refreshToken.UserID, cfg.tokenSecret, time.Second*time.Duration(3600))
+	// Insert replacement refresh token into the same family (recording where the session was last used)
+	dbParams := rotatedRefreshToken(refreshToken, auth.HashRefreshToken(newRefreshToken), clientUserAgent(rq), clientIP(rq))
+
+	_, err = qtx.CreateRefreshToken(rq.Context(), dbParams)
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Mark the session as used now
+	err = qtx.TouchSession(rq.Context(), refreshToken.FamilyID)
 	if err != nil {
 		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
 		if err != nil {
@@ -63,8 +288,17 @@ func (cfg *apiConfig) tokenRefreshHandler(rw http.ResponseWriter, rq *http.Reque
 		return
 	}
 
-	// Respond with refreshed access token
-	err = respondWithJSON(rw, http.StatusOK, RefreshedAccessToken{Token: accessToken})
+	err = tx.Commit()
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Respond with refreshed access and refresh tokens
+	err = respondWithJSON(rw, http.StatusOK, RefreshedAccessToken{Token: accessToken, RefreshToken: newRefreshToken})
 	if err != nil {
 		log.Printf("Error responding: %v", err)
 	}
@@ -85,7 +319,49 @@ func (cfg *apiConfig) tokenRevokeHandler(rw http.ResponseWriter, rq *http.Reques
 	}
 
 	// Revoke refresh token
-	err = cfg.db.RevokeRefreshToken(rq.Context(), token)
+	err = cfg.db.RevokeRefreshToken(rq.Context(), auth.HashRefreshToken(token))
+	if err != nil {
+		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Respond with success (no content)
+	respondWithNoContent(rw)
+}
+
+func (cfg *apiConfig) logoutHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate bearer token
+	token, err := auth.GetBearerToken(rq.Header)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	accessToken, err := cfg.validateAccessToken(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Revoke access token so it stops working before it expires
+	err = cfg.revokeAccessToken(rq.Context(), accessToken)
+	if errors.Is(err, errAccessTokenNotRevocable) {
+		err = respondWithError(rw, http.StatusBadRequest, "Token can't be revoked, refresh it first")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
 	if err != nil {
 		err = respondWithError(rw, http.StatusInternalServerError, "Internal Server Error")
 		if err != nil {
@@ -97,3 +373,15 @@ func (cfg *apiConfig) tokenRevokeHandler(rw http.ResponseWriter, rq *http.Reques
 	// Respond with success (no content)
 	respondWithNoContent(rw)
 }
+
+// jwksHandler publishes the public signing keys so other services can verify
+// access tokens without sharing a secret.
+func (cfg *apiConfig) jwksHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Let verifiers cache the keys for a while (new keys should be published well before they sign)
+	rw.Header().Set("Cache-Control", "public, max-age=300")
+
+	err := respondWithJSON(rw, http.StatusOK, cfg.keyring.JWKS())
+	if err != nil {
+		log.Printf("Error responding: %v", err)
+	}
+}
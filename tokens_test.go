@@ -0,0 +1,69 @@
+package main
+
+import (
+	"database/sql"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenReused(t *testing.T) {
+	revokedAt := sql.NullTime{Time: time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC), Valid: true}
+	reason := func(s string) sql.NullString {
+		return sql.NullString{String: s, Valid: true}
+	}
+
+	tests := []struct {
+		name  string
+		token database.RefreshToken
+		want  bool
+	}{
+		{"Active", database.RefreshToken{}, false},
+		{"Rotated", database.RefreshToken{RevokedAt: revokedAt, RevokedReason: reason("rotated")}, true},
+		{"LoggedOut", database.RefreshToken{RevokedAt: revokedAt, RevokedReason: reason("logout")}, false},
+		{"SessionRevoked", database.RefreshToken{RevokedAt: revokedAt, RevokedReason: reason("session")}, false},
+		{"FamilyRevoked", database.RefreshToken{RevokedAt: revokedAt, RevokedReason: reason("reuse")}, false},
+		{"NoReason", database.RefreshToken{RevokedAt: revokedAt}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refreshTokenReused(tt.token)
+			if got != tt.want {
+				t.Errorf("refreshTokenReused() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotatedRefreshToken(t *testing.T) {
+	expiresAt := time.Date(2024, 12, 1, 12, 0, 0, 0, time.UTC)
+	old := database.RefreshToken{
+		TokenHash: "old",
+		UserID:    uuid.New(),
+		ExpiresAt: expiresAt,
+		FamilyID:  uuid.New(),
+		UserAgent: "Mozilla/5.0",
+		IpAddress: "203.0.113.7",
+	}
+
+	got := rotatedRefreshToken(old, "new", "curl/8.0", "198.51.100.1")
+	want := database.CreateRefreshTokenParams{
+		TokenHash: "new",
+		UserID:    old.UserID,
+		ExpiresAt: expiresAt,
+		FamilyID:  old.FamilyID,
+		UserAgent: "curl/8.0",
+		IpAddress: "198.51.100.1",
+	}
+	if got != want {
+		t.Errorf("rotatedRefreshToken() got = %v, want %v", got, want)
+	}
+
+	// Rotating the replacement again keeps the family's original expiry
+	next := rotatedRefreshToken(database.RefreshToken{UserID: got.UserID, ExpiresAt: got.ExpiresAt, FamilyID: got.FamilyID}, "next", "", "")
+	if !next.ExpiresAt.Equal(expiresAt) || next.FamilyID != old.FamilyID {
+		t.Errorf("rotatedRefreshToken() got = %v/%v, want %v/%v", next.ExpiresAt, next.FamilyID, expiresAt, old.FamilyID)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
 	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
@@ -18,11 +19,18 @@ type User struct {
 	Token        string    `json:"token,omitempty"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Handle       string    `json:"handle,omitempty"`
+	DisplayName  string    `json:"display_name"`
+	Bio          string    `json:"bio"`
+	Location     string    `json:"location"`
+	Website      string    `json:"website"`
+	AvatarURL    string    `json:"avatar_url"`
 }
 
 type CreateUserParams struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Handle   string `json:"handle"`
 }
 
 type LoginParams struct {
@@ -33,6 +41,24 @@ type LoginParams struct {
 type UpdateUserParams struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Handle   string `json:"handle"`
+}
+
+// Map database user to User struct
+func userFromDB(dbUser database.User) User {
+	return User{
+		ID:          dbUser.ID,
+		CreatedAt:   dbUser.CreatedAt,
+		UpdatedAt:   dbUser.UpdatedAt,
+		Email:       dbUser.Email,
+		IsChirpyRed: dbUser.IsChirpyRed,
+		Handle:      dbUser.Handle.String,
+		DisplayName: dbUser.DisplayName,
+		Bio:         dbUser.Bio,
+		Location:    dbUser.Location,
+		Website:     dbUser.Website,
+		AvatarURL:   dbUser.AvatarUrl,
+	}
 }
 
 func (cfg *apiConfig) createUserHandler(rw http.ResponseWriter, rq *http.Request) {
@@ -68,6 +94,15 @@ func (cfg *apiConfig) createUserHandler(rw http.ResponseWriter, rq *http.Request
 		return
 	}
 
+	// Return error if handle is not valid
+	if len(params.Handle) > 0 && !validHandle(params.Handle) {
+		err = respondWithError(rw, http.StatusBadRequest, "Handle must be 3 to 30 letters, digits or underscores")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
 	// Hash user password
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
@@ -82,9 +117,17 @@ func (cfg *apiConfig) createUserHandler(rw http.ResponseWriter, rq *http.Request
 	dbParams := database.CreateUserParams{
 		HashedPassword: hashedPassword,
 		Email:          params.Email,
+		Handle:         sql.NullString{String: params.Handle, Valid: len(params.Handle) > 0},
 	}
 
 	dbUser, err := cfg.db.CreateUser(rq.Context(), dbParams)
+	if isUniqueViolation(err) {
+		err = respondWithError(rw, http.StatusConflict, "Email or handle already in use")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
 	if err != nil {
 		err = respondWithError(rw, http.StatusInternalServerError, "Error creating user")
 		if err != nil {
@@ -94,13 +137,7 @@ func (cfg *apiConfig) createUserHandler(rw http.ResponseWriter, rq *http.Request
 	}
 
 	// Map database user to User struct
-	user := User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed,
-	}
+	user := userFromDB(dbUser)
 
 	// Return user
 	err = respondWithJSON(rw, http.StatusCreated, user)
@@ -162,8 +199,11 @@ func (cfg *apiConfig) loginHandler(rw http.ResponseWriter, rq *http.Request) {
 		return
 	}
 
+	// Each login starts a new session (refresh token family) the access token is tied to
+	familyID := uuid.New()
+
 	// Create access token
-	token, err := auth.MakeJWT(dbUser.ID, cfg.tokenSecret, time.Second*time.Duration(3600))
+	token, err := auth.MakeSessionJWT(dbUser.ID, familyID, cfg.keyring, time.Second*time.Duration(3600))
 	if err != nil {
 		err = respondWithError(rw, http.StatusInternalServerError, "Error creating token")
 		if err != nil {
@@ -182,13 +222,17 @@ func (cfg *apiConfig) loginHandler(rw http.ResponseWriter, rq *http.Request) {
 		return
 	}
 
-	// Insert refresh token into database
+	// Insert refresh token into database (refreshes rotate within its family)
 	dbParams := database.CreateRefreshTokenParams{
-		Token:  refreshToken,
-		UserID: dbUser.ID,
+		TokenHash: auth.HashRefreshToken(refreshToken),
+		UserID:    dbUser.ID,
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL),
+		FamilyID:  familyID,
+		UserAgent: clientUserAgent(rq),
+		IpAddress: clientIP(rq),
 	}
 
-	dbRefreshToken, err := cfg.db.CreateRefreshToken(rq.Context(), dbParams)
+	_, err = cfg.db.CreateRefreshToken(rq.Context(), dbParams)
 	if err != nil {
 		err = respondWithError(rw, http.StatusInternalServerError, "Error creating refresh token")
 		if err != nil {
@@ -198,15 +242,9 @@ func (cfg *apiConfig) loginHandler(rw http.ResponseWriter, rq *http.Request) {
 	}
 
 	// Map database user to User struct
-	user := User{
-		ID:           dbUser.ID,
-		CreatedAt:    dbUser.CreatedAt,
-		UpdatedAt:    dbUser.UpdatedAt,
-		Email:        dbUser.Email,
-		Token:        token,
-		RefreshToken: dbRefreshToken.Token,
-		IsChirpyRed:  dbUser.IsChirpyRed,
-	}
+	user := userFromDB(dbUser)
+	user.Token = token
+	user.RefreshToken = refreshToken
 
 	// Return user
 	err = respondWithJSON(rw, http.StatusOK, user)
@@ -241,7 +279,7 @@ func (cfg *apiConfig) updateUserHandler(rw http.ResponseWriter, rq *http.Request
 	}
 
 	// Validate JWT
-	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	userID, err := cfg.validateJWT(rq.Context(), token)
 	if err != nil {
 		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
 		if err != nil {
@@ -268,6 +306,15 @@ func (cfg *apiConfig) updateUserHandler(rw http.ResponseWriter, rq *http.Request
 		return
 	}
 
+	// Return error if handle is not valid
+	if len(params.Handle) > 0 && !validHandle(params.Handle) {
+		err = respondWithError(rw, http.StatusBadRequest, "Handle must be 3 to 30 letters, digits or underscores")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
 	// Hash user password
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
@@ -282,10 +329,18 @@ func (cfg *apiConfig) updateUserHandler(rw http.ResponseWriter, rq *http.Request
 	dbParams := database.UpdateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPassword,
+		Handle:         sql.NullString{String: params.Handle, Valid: len(params.Handle) > 0},
 		ID:             userID,
 	}
 
 	dbUser, err := cfg.db.UpdateUser(rq.Context(), dbParams)
+	if isUniqueViolation(err) {
+		err = respondWithError(rw, http.StatusConflict, "Email or handle already in use")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
 	if err != nil {
 		err = respondWithError(rw, http.StatusInternalServerError, "Error updating user")
 		if err != nil {
@@ -295,13 +350,7 @@ func (cfg *apiConfig) updateUserHandler(rw http.ResponseWriter, rq *http.Request
 	}
 
 	// Map database user to User struct
-	user := User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed,
-	}
+	user := userFromDB(dbUser)
 
 	// Return user
 	err = respondWithJSON(rw, http.StatusOK, user)
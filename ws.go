@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/coder/websocket"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// wsPingInterval is also how often the access token is checked against the
+// denylist (a var so tests can shorten it)
+var wsPingInterval = 30 * time.Second
+
+const (
+	wsPingTimeout  = 10 * time.Second
+	wsWriteTimeout = 10 * time.Second
+	wsSendBuffer   = 32
+	wsReadLimit    = 4096
+
+	// Close code sent when the access token runs out or is revoked, so
+	// clients know to refresh it and reconnect
+	wsStatusTokenExpired websocket.StatusCode = 4001
+)
+
+type wsClientMessage struct {
+	Type    string    `json:"type"`
+	Channel string    `json:"channel"`
+	ChirpID uuid.UUID `json:"chirp_id"`
+	Token   string    `json:"token"`
+}
+
+type wsServerMessage struct {
+	Type         string        `json:"type"`
+	Channel      string        `json:"channel,omitempty"`
+	ChirpID      *uuid.UUID    `json:"chirp_id,omitempty"`
+	Event        string        `json:"event,omitempty"`
+	EventID      int64         `json:"event_id,omitempty"`
+	Chirp        *Chirp        `json:"chirp,omitempty"`
+	Notification *Notification `json:"notification,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// wsChirpGetter looks up the chirps clients subscribe to threads by and the
+// users whose chirps they don't want to see.
+type wsChirpGetter interface {
+	GetChirpById(ctx context.Context, id uuid.UUID) (database.Chirp, error)
+	GetHiddenUserIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// wsSession is one authenticated WebSocket connection and the channels it
+// subscribed to.
+type wsSession struct {
+	cfg    *apiConfig
+	chirps wsChirpGetter
+	conn   *websocket.Conn
+	userID uuid.UUID
+	send   chan wsServerMessage
+	reauth chan auth.AccessToken
+	cancel context.CancelFunc
+
+	mu            sync.Mutex
+	timeline      map[uuid.UUID]bool
+	threads       map[uuid.UUID]bool
+	hidden        hiddenUsers
+	notifications bool
+}
+
+// wsAccessToken reads the access token from the Authorization header, or
+// from the access_token query parameter since browsers can't set headers on
+// WebSocket requests.
+func wsAccessToken(rq *http.Request) (string, error) {
+	if token := rq.URL.Query().Get("access_token"); len(token) > 0 {
+		return token, nil
+	}
+	return auth.GetBearerToken(rq.Header)
+}
+
+func (cfg *apiConfig) wsHandler(rw http.ResponseWriter, rq *http.Request) {
+	// Validate access token
+	token, err := wsAccessToken(rq)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Validate JWT
+	accessToken, err := cfg.validateAccessToken(rq.Context(), token)
+	if err != nil {
+		err = respondWithError(rw, http.StatusUnauthorized, "Unauthorized")
+		if err != nil {
+			log.Printf("Error responding: %v", err)
+		}
+		return
+	}
+
+	// Upgrade connection (Accept writes its own error response)
+	conn, err := websocket.Accept(rw, rq, nil)
+	if err != nil {
+		log.Printf("Error accepting WebSocket: %v", err)
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsReadLimit)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	session := &wsSession{
+		cfg:    cfg,
+		chirps: cfg.db,
+		conn:   conn,
+		userID: accessToken.UserID,
+		send:   make(chan wsServerMessage, wsSendBuffer),
+		reauth: make(chan auth.AccessToken, 1),
+		cancel: cancel,
+	}
+
+	go session.writeLoop(ctx, accessToken)
+	session.readLoop(ctx)
+}
+
+// enqueue queues msg for the write loop. Clients that stop reading fill their
+// queue and are disconnected rather than holding up everyone else.
+func (s *wsSession) enqueue(msg wsServerMessage) {
+	select {
+	case s.send <- msg:
+	default:
+		s.conn.Close(websocket.StatusTryAgainLater, "client too slow")
+		s.cancel()
+	}
+}
+
+func (s *wsSession) readLoop(ctx context.Context) {
+	for {
+		_, data, err := s.conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		msg := wsClientMessage{}
+		err = json.Unmarshal(data, &msg)
+		if err != nil {
+			s.enqueue(wsServerMessage{Type: "error", Error: "Invalid message"})
+			continue
+		}
+
+		switch msg.Type {
+		case "subscribe":
+			s.subscribe(ctx, msg)
+		case "unsubscribe":
+			s.unsubscribe(ctx, msg)
+		case "auth":
+			s.refreshToken(ctx, msg)
+		default:
+			s.enqueue(wsServerMessage{Type: "error", Error: "Unknown message type"})
+		}
+	}
+}
+
+func (s *wsSession) subscribe(ctx context.Context, msg wsClientMessage) {
+	// Chirp channels leave out blocked and muted users, read once per
+	// subscription like the follow list
+	if msg.Channel == "timeline" || msg.Channel == "thread" {
+		hiddenIDs, err := s.chirps.GetHiddenUserIDs(ctx, s.userID)
+		if err != nil {
+			s.enqueue(wsServerMessage{Type: "error", Channel: msg.Channel, Error: "Error getting blocked users"})
+			return
+		}
+
+		s.mu.Lock()
+		s.hidden = newHiddenUsers(hiddenIDs)
+		s.mu.Unlock()
+	}
+
+	switch msg.Channel {
+	case "timeline":
+		// The follow list is read once per subscription
+		followeeIDs, err := s.cfg.db.GetFolloweeIDs(ctx, s.userID)
+		if err != nil {
+			s.enqueue(wsServerMessage{Type: "error", Channel: msg.Channel, Error: "Error getting followed users"})
+			return
+		}
+
+		followees := make(map[uuid.UUID]bool, len(followeeIDs))
+		for _, id := range followeeIDs {
+			followees[id] = true
+		}
+
+		s.mu.Lock()
+		s.timeline = followees
+		s.mu.Unlock()
+		s.enqueue(wsServerMessage{Type: "subscribed", Channel: msg.Channel})
+	case "thread":
+		// Subscribing to any chirp in a thread subscribes to the whole thread
+		dbChirp, err := s.chirps.GetChirpById(ctx, msg.ChirpID)
+		if err != nil {
+			s.enqueue(wsServerMessage{Type: "error", Channel: msg.Channel, Error: "Chirp not found"})
+			return
+		}
+		threadID := chirpFromDB(dbChirp).ThreadID
+
+		s.mu.Lock()
+		if s.threads == nil {
+			s.threads = make(map[uuid.UUID]bool)
+		}
+		s.threads[threadID] = true
+		s.mu.Unlock()
+		s.enqueue(wsServerMessage{Type: "subscribed", Channel: msg.Channel, ChirpID: &threadID})
+	case "notifications":
+		s.mu.Lock()
+		s.notifications = true
+		s.mu.Unlock()
+		s.enqueue(wsServerMessage{Type: "subscribed", Channel: msg.Channel})
+	default:
+		s.enqueue(wsServerMessage{Type: "error", Channel: msg.Channel, Error: "Unknown channel"})
+	}
+}
+
+func (s *wsSession) unsubscribe(ctx context.Context, msg wsClientMessage) {
+	// Threads are subscribed by their root, so resolve it the same way
+	// (a chirp deleted since then can only be unsubscribed by its root)
+	threadID := msg.ChirpID
+	if msg.Channel == "thread" {
+		dbChirp, err := s.chirps.GetChirpById(ctx, msg.ChirpID)
+		if err == nil {
+			threadID = chirpFromDB(dbChirp).ThreadID
+		}
+	}
+
+	// Build the reply under the lock but enqueue it after, since enqueue can
+	// close the connection while the write loop waits on the lock
+	reply := wsServerMessage{Type: "unsubscribed", Channel: msg.Channel}
+	s.mu.Lock()
+	switch msg.Channel {
+	case "timeline":
+		s.timeline = nil
+	case "thread":
+		delete(s.threads, threadID)
+		reply.ChirpID = &threadID
+	case "notifications":
+		s.notifications = false
+	default:
+		reply = wsServerMessage{Type: "error", Channel: msg.Channel, Error: "Unknown channel"}
+	}
+	s.mu.Unlock()
+
+	s.enqueue(reply)
+}
+
+// refreshToken lets clients hand over a new access token before the current
+// one expires instead of reconnecting.
+func (s *wsSession) refreshToken(ctx context.Context, msg wsClientMessage) {
+	accessToken, err := s.cfg.validateAccessToken(ctx, msg.Token)
+	if err != nil || accessToken.UserID != s.userID {
+		s.enqueue(wsServerMessage{Type: "error", Error: "Unauthorized"})
+		return
+	}
+
+	// Only the latest token matters
+	select {
+	case <-s.reauth:
+	default:
+	}
+	s.reauth <- accessToken
+	s.enqueue(wsServerMessage{Type: "authenticated"})
+}
+
+// chirpMessages returns a message for every channel subscribed to event.
+func (s *wsSession) chirpMessages(event ChirpEvent) []wsServerMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hidden.hides(event.Chirp) {
+		return nil
+	}
+
+	var msgs []wsServerMessage
+	if s.timeline != nil && s.timeline[event.Chirp.UserID] {
+		chirp := event.Chirp
+		msgs = append(msgs, wsServerMessage{Type: "chirp", Channel: "timeline", Event: event.Type, EventID: event.ID, Chirp: &chirp})
+	}
+	if s.threads[event.Chirp.ThreadID] {
+		chirp, threadID := event.Chirp, event.Chirp.ThreadID
+		msgs = append(msgs, wsServerMessage{Type: "chirp", Channel: "thread", ChirpID: &threadID, Event: event.Type, EventID: event.ID, Chirp: &chirp})
+	}
+	return msgs
+}
+
+func (s *wsSession) wantsNotifications() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notifications
+}
+
+// writeLoop owns all writes to the connection: queued messages, events from
+// the brokers, keepalive pings and the close when the token expires or is
+// revoked.
+func (s *wsSession) writeLoop(ctx context.Context, accessToken auth.AccessToken) {
+	defer s.cancel()
+
+	chirpSub, _ := s.cfg.broker.Subscribe(0)
+	defer s.cfg.broker.Unsubscribe(chirpSub)
+
+	notificationSub := s.cfg.notificationHub.Subscribe(s.userID)
+	defer s.cfg.notificationHub.Unsubscribe(s.userID, notificationSub)
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	// Tokens without an expiry never time out
+	expiry := time.NewTimer(time.Until(accessToken.ExpiresAt))
+	if accessToken.ExpiresAt.IsZero() {
+		expiry.Stop()
+	}
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case accessToken = <-s.reauth:
+			expiry.Stop()
+			if !accessToken.ExpiresAt.IsZero() {
+				expiry.Reset(time.Until(accessToken.ExpiresAt))
+			}
+		case <-expiry.C:
+			s.conn.Close(wsStatusTokenExpired, "token expired")
+			return
+		case <-ping.C:
+			// Ping waits for the pong, which the read loop receives
+			pingCtx, cancel := context.WithTimeout(ctx, wsPingTimeout)
+			err := s.conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				s.conn.Close(websocket.StatusGoingAway, "ping timeout")
+				return
+			}
+
+			// Logging out or revoking the session ends open connections too
+			err = s.cfg.checkAccessTokenRevoked(ctx, accessToken)
+			if errors.Is(err, errAccessTokenRevoked) {
+				s.conn.Close(wsStatusTokenExpired, "token revoked")
+				return
+			}
+			if err != nil {
+				log.Printf("Error checking WebSocket access token: %v", err)
+			}
+		case event, ok := <-chirpSub.events:
+			if !ok {
+				s.conn.Close(websocket.StatusTryAgainLater, "client too slow")
+				return
+			}
+			for _, msg := range s.chirpMessages(event) {
+				s.enqueue(msg)
+			}
+		case notification, ok := <-notificationSub.events:
+			if !ok {
+				s.conn.Close(websocket.StatusTryAgainLater, "client too slow")
+				return
+			}
+			if s.wantsNotifications() {
+				s.enqueue(wsServerMessage{Type: "notification", Notification: &notification})
+			}
+		case msg := <-s.send:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				log.Printf("Error encoding WebSocket message: %v", err)
+				continue
+			}
+
+			writeCtx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+			err = s.conn.Write(writeCtx, websocket.MessageText, data)
+			cancel()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
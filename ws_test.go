@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/coder/websocket"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/auth"
+	"github.com/ehafenmaier/boot-dev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDenylist holds the IDs of revoked access tokens and sessions in memory.
+// Open connections check it while tests revoke, hence the mutex.
+type fakeDenylist struct {
+	mu      sync.Mutex
+	revoked map[string]bool
+}
+
+func (d *fakeDenylist) revoke(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.revoked[id] = true
+}
+
+func (d *fakeDenylist) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.revoked[tokenID], nil
+}
+
+func (d *fakeDenylist) IsSessionRevoked(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.revoked[familyID.String()], nil
+}
+
+// fakeChirps holds the chirps threads can be subscribed by in memory.
+type fakeChirps map[uuid.UUID]database.Chirp
+
+func (c fakeChirps) GetChirpById(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	dbChirp, ok := c[id]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return dbChirp, nil
+}
+
+func (c fakeChirps) GetHiddenUserIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+// fakeHiddenChirps also hides the given users from every session.
+type fakeHiddenChirps struct {
+	fakeChirps
+	hidden []uuid.UUID
+}
+
+func (c fakeHiddenChirps) GetHiddenUserIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return c.hidden, nil
+}
+
+func newTestWSServer(t *testing.T) (*apiConfig, string) {
+	keyring, err := auth.NewHMACKeyring("test", "mysecret")
+	if err != nil {
+		t.Fatalf("NewHMACKeyring() error = %v", err)
+	}
+
+	cfg := &apiConfig{
+		keyring:         keyring,
+		denylist:        &fakeDenylist{revoked: map[string]bool{}},
+		broker:          newChirpBroker(chirpEventHistorySize),
+		notificationHub: newNotificationHub(),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(cfg.wsHandler))
+	t.Cleanup(srv.Close)
+	return cfg, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func readWSMessage(t *testing.T, ctx context.Context, conn *websocket.Conn) wsServerMessage {
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	msg := wsServerMessage{}
+	err = json.Unmarshal(data, &msg)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return msg
+}
+
+func TestWSHandlerUnauthorized(t *testing.T) {
+	cfg, url := newTestWSServer(t)
+
+	// Revoked tokens are rejected even though they haven't expired
+	revokedToken, _ := auth.MakeJWT(uuid.New(), cfg.keyring, time.Hour)
+	accessToken, _ := auth.ParseAccessToken(revokedToken, cfg.keyring)
+	cfg.denylist.(*fakeDenylist).revoke(accessToken.ID)
+
+	// So are tokens whose session was revoked
+	sessionID := uuid.New()
+	revokedSessionToken, _ := auth.MakeSessionJWT(uuid.New(), sessionID, cfg.keyring, time.Hour)
+	cfg.denylist.(*fakeDenylist).revoke(sessionID.String())
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"MissingToken", ""},
+		{"InvalidToken", "invalidtoken"},
+		{"RevokedToken", revokedToken},
+		{"RevokedSession", revokedSessionToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, resp, err := websocket.Dial(ctx, url+"?access_token="+tt.token, nil)
+			if err == nil {
+				t.Fatalf("Dial() succeeded, want error")
+			}
+			if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("Dial() response = %v, want 401", resp)
+			}
+		})
+	}
+}
+
+func TestWSHandlerNotifications(t *testing.T) {
+	cfg, url := newTestWSServer(t)
+	userID := uuid.New()
+	token, _ := auth.MakeJWT(userID, cfg.keyring, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, url+"?access_token="+token, nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+
+	err = conn.Write(ctx, websocket.MessageText, []byte(`{"type":"subscribe","channel":"notifications"}`))
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if msg := readWSMessage(t, ctx, conn); msg.Type != "subscribed" || msg.Channel != "notifications" {
+		t.Fatalf("got %+v, want subscribed to notifications", msg)
+	}
+
+	// Notifications for other users are not delivered
+	notificationID := uuid.New()
+	cfg.notificationHub.Publish(ctx, []database.Notification{
+		{ID: uuid.New(), UserID: uuid.New(), ActorID: userID, Kind: "follow"},
+		{ID: notificationID, UserID: userID, ActorID: uuid.New(), Kind: "follow"},
+	})
+
+	msg := readWSMessage(t, ctx, conn)
+	if msg.Type != "notification" || msg.Notification == nil || msg.Notification.ID != notificationID {
+		t.Errorf("got %+v, want notification %v", msg, notificationID)
+	}
+}
+
+func TestWSHandlerTokenExpiry(t *testing.T) {
+	cfg, url := newTestWSServer(t)
+	token, _ := auth.MakeJWT(uuid.New(), cfg.keyring, 1500*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, url+"?access_token="+token, nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+
+	_, _, err = conn.Read(ctx)
+	if websocket.CloseStatus(err) != wsStatusTokenExpired {
+		t.Errorf("Read() error = %v, want close status %d", err, wsStatusTokenExpired)
+	}
+}
+
+func TestWSHandlerTokenRevoked(t *testing.T) {
+	interval := wsPingInterval
+	wsPingInterval = 100 * time.Millisecond
+	t.Cleanup(func() { wsPingInterval = interval })
+
+	cfg, url := newTestWSServer(t)
+
+	tests := []struct {
+		name          string
+		revokeSession bool
+	}{
+		{"RevokedToken", false},
+		{"RevokedSession", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			sessionID := uuid.New()
+			token, _ := auth.MakeSessionJWT(uuid.New(), sessionID, cfg.keyring, time.Hour)
+			accessToken, _ := auth.ParseAccessToken(token, cfg.keyring)
+			revokedID := accessToken.ID
+			if tt.revokeSession {
+				revokedID = sessionID.String()
+			}
+
+			conn, _, err := websocket.Dial(ctx, url+"?access_token="+token, nil)
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.CloseNow()
+
+			// Logging out after connecting closes the connection on the next ping
+			cfg.denylist.(*fakeDenylist).revoke(revokedID)
+
+			_, _, err = conn.Read(ctx)
+			if websocket.CloseStatus(err) != wsStatusTokenExpired {
+				t.Errorf("Read() error = %v, want close status %d", err, wsStatusTokenExpired)
+			}
+		})
+	}
+}
+
+func TestWSSessionUnsubscribeThread(t *testing.T) {
+	root := database.Chirp{ID: uuid.New()}
+	reply := database.Chirp{ID: uuid.New(), ThreadID: uuid.NullUUID{UUID: root.ID, Valid: true}}
+	chirps := fakeChirps{root.ID: root, reply.ID: reply}
+	deletedID := uuid.New()
+
+	tests := []struct {
+		name          string
+		subscribeID   uuid.UUID
+		unsubscribeID uuid.UUID
+		wantThreads   int
+	}{
+		{"ByRoot", root.ID, root.ID, 0},
+		{"ByReply", reply.ID, reply.ID, 0},
+		{"SubscribedByReplyUnsubscribedByRoot", reply.ID, root.ID, 0},
+		{"DeletedChirp", root.ID, deletedID, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			session := &wsSession{chirps: chirps, send: make(chan wsServerMessage, wsSendBuffer)}
+
+			session.subscribe(ctx, wsClientMessage{Type: "subscribe", Channel: "thread", ChirpID: tt.subscribeID})
+			session.unsubscribe(ctx, wsClientMessage{Type: "unsubscribe", Channel: "thread", ChirpID: tt.unsubscribeID})
+
+			if got := len(session.threads); got != tt.wantThreads {
+				t.Errorf("unsubscribe() threads = %d, want %d", got, tt.wantThreads)
+			}
+
+			subscribed, unsubscribed := <-session.send, <-session.send
+			if subscribed.Type != "subscribed" || unsubscribed.Type != "unsubscribed" {
+				t.Fatalf("got messages %q, %q, want subscribed, unsubscribed", subscribed.Type, unsubscribed.Type)
+			}
+			if tt.wantThreads == 0 && *unsubscribed.ChirpID != root.ID {
+				t.Errorf("unsubscribe() chirp_id = %v, want %v", *unsubscribed.ChirpID, root.ID)
+			}
+		})
+	}
+}
+
+func TestWSSessionChirpMessagesHidden(t *testing.T) {
+	root := database.Chirp{ID: uuid.New()}
+	authorID, hiddenID := uuid.New(), uuid.New()
+	chirps := fakeHiddenChirps{fakeChirps{root.ID: root}, []uuid.UUID{hiddenID}}
+
+	tests := []struct {
+		name  string
+		chirp Chirp
+		want  int
+	}{
+		{"ByAuthor", Chirp{UserID: authorID, ThreadID: root.ID}, 2},
+		{"ByHiddenUser", Chirp{UserID: hiddenID, ThreadID: root.ID}, 0},
+		{"RechirpOfHiddenUser", Chirp{UserID: authorID, ThreadID: root.ID, Original: &Chirp{UserID: hiddenID}}, 0},
+		{"QuoteOfAuthor", Chirp{UserID: authorID, ThreadID: root.ID, Original: &Chirp{UserID: authorID}}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &wsSession{chirps: chirps, send: make(chan wsServerMessage, wsSendBuffer)}
+			session.subscribe(context.Background(), wsClientMessage{Type: "subscribe", Channel: "thread", ChirpID: root.ID})
+			if msg := <-session.send; msg.Type != "subscribed" {
+				t.Fatalf("subscribe() got message %q, want subscribed", msg.Type)
+			}
+			session.timeline = map[uuid.UUID]bool{authorID: true, hiddenID: true}
+
+			if got := len(session.chirpMessages(ChirpEvent{Type: "created", Chirp: tt.chirp})); got != tt.want {
+				t.Errorf("chirpMessages() got = %d messages, want %d", got, tt.want)
+			}
+		})
+	}
+}